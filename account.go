@@ -0,0 +1,303 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const HASH_ITERATIONS = 100000 // Number of PBKDF2 rounds used to slow down guessing of passwords
+const HASH_LENGTH = 32
+const SALT_LENGTH = 16
+
+var errInvalidPassword = errors.New("invalid password")
+
+/*
+PlayerRecord is the form in which a player is kept on disk between sessions
+Equipped armour and weapons are recorded by the description of the item within the inventory
+*/
+type PlayerRecord struct {
+	Name         string       `json:"name"`
+	PasswordHash string       `json:"passwordHash"`
+	Health       int          `json:"health"`
+	Gold         int          `json:"gold"`
+	Town         string       `json:"town"`
+	X            int          `json:"x"`
+	Y            int          `json:"y"`
+	Inventory    []ItemRecord `json:"inventory"`
+	Armour       string       `json:"armour,omitempty"`
+	Weapon       string       `json:"weapon,omitempty"`
+	Channels     []string     `json:"channels,omitempty"`
+	Muted        []string     `json:"muted,omitempty"`
+	Ignored      []string     `json:"ignored,omitempty"`
+	Recipes      []string     `json:"recipes,omitempty"`
+}
+
+type ItemRecord struct {
+	ID          string   `json:"id,omitempty"`
+	Description string   `json:"description"`
+	Type        ItemType `json:"type"`
+	Quantity    int      `json:"quantity,omitempty"`
+}
+
+/*
+AccountStore keeps a JSON file for every player within a directory
+It also tracks which accounts are currently in use so a name can only be played by one connection at a time
+*/
+type AccountStore struct {
+	directory string
+	mutex     sync.Mutex
+	online    map[string]bool
+}
+
+var accountStore *AccountStore
+
+func NewAccountStore(directory string) *AccountStore {
+	a := new(AccountStore)
+	a.directory = directory
+	a.online = make(map[string]bool)
+	return a
+}
+
+// Names are case insensitive so "Bob" and "bob" are the same account
+func (store *AccountStore) path(name string) string {
+	return filepath.Join(store.directory, strings.ToLower(name)+".json")
+}
+
+func (store *AccountStore) exists(name string) bool {
+	_, err := os.Stat(store.path(name))
+	return err == nil
+}
+
+// Reserve a name for a connection, failing if somebody else is already using it
+func (store *AccountStore) claim(name string) bool {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	key := strings.ToLower(name)
+	if store.online[key] {
+		return false
+	}
+
+	store.online[key] = true
+	return true
+}
+
+func (store *AccountStore) release(name string) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.online, strings.ToLower(name))
+}
+
+// Retrieve a player's record after checking their password
+func (store *AccountStore) load(name string, password string) (*PlayerRecord, error) {
+	content, err := os.ReadFile(store.path(name))
+	if err != nil {
+		return nil, err
+	}
+
+	record := new(PlayerRecord)
+	if err := json.Unmarshal(content, record); err != nil {
+		return nil, err
+	}
+
+	if !checkPassword(password, record.PasswordHash) {
+		return nil, errInvalidPassword
+	}
+
+	return record, nil
+}
+
+// Write a player's record to disk (written to a temporary file first so a crash never leaves half a record)
+func (store *AccountStore) save(record *PlayerRecord) error {
+	if err := os.MkdirAll(store.directory, 0755); err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := store.path(record.Name)
+	if err := os.WriteFile(path+".tmp", content, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// Convert a player into a record which can be saved
+func (player *Player) toRecord() *PlayerRecord {
+	record := new(PlayerRecord)
+	record.Name = player.name
+	record.PasswordHash = player.passwordHash
+	record.Health = player.health
+	record.Gold = player.gold
+	record.Town = player.currentTown.name
+	record.X = player.coordinates.x
+	record.Y = player.coordinates.y
+
+	for _, item := range player.inventory {
+		record.Inventory = append(record.Inventory, item.toRecord())
+	}
+
+	if player.armour != nil {
+		record.Armour = player.armour.description
+	}
+
+	if player.weapon != nil {
+		record.Weapon = player.weapon.description
+	}
+
+	record.Channels = sortedKeys(player.channels)
+	record.Muted = sortedKeys(player.muted)
+	record.Ignored = sortedKeys(player.ignored)
+	record.Recipes = sortedKeys(player.recipes)
+
+	return record
+}
+
+// Restore a player from a saved record, moving them somewhere walkable if their town or position no longer exists
+func (player *Player) applyRecord(record *PlayerRecord, world *World) {
+	player.name = record.Name
+	player.passwordHash = record.PasswordHash
+	player.health = record.Health
+	player.gold = record.Gold
+
+	player.inventory = make([]Item, 0, len(record.Inventory))
+	for _, itemRecord := range record.Inventory {
+		player.addToInventory(itemFromRecord(itemRecord, *NewPoint(record.X, record.Y)))
+	}
+
+	player.armour = nil
+	player.weapon = nil
+
+	for _, item := range player.inventory {
+		item := item
+		if record.Armour != "" && item.description == record.Armour {
+			player.armour = &item
+		} else if record.Weapon != "" && item.description == record.Weapon {
+			player.weapon = &item
+		}
+	}
+
+	player.channels = make(map[string]bool)
+	for _, channel := range record.Channels {
+		player.channels[channel] = true
+	}
+
+	player.muted = make(map[string]bool)
+	for _, channel := range record.Muted {
+		player.muted[channel] = true
+	}
+
+	player.ignored = make(map[string]bool)
+	for _, name := range record.Ignored {
+		player.ignored[name] = true
+	}
+
+	// Recipes which have since been removed from the recipe book are forgotten
+	player.recipes = make(map[string]bool)
+	for _, id := range record.Recipes {
+		if _, ok := recipeBook.byID[id]; ok {
+			player.recipes[id] = true
+		}
+	}
+
+	if town := world.findTown(record.Town); town != nil {
+		player.currentTown = town
+	}
+
+	player.coordinates = NewPoint(record.X, record.Y)
+	if record.X < 0 || record.X >= config.Width || record.Y < 0 || record.Y >= config.Height || !player.isWithinPlayableRegion() {
+		player.coordinates = findFreeLocationInDungeon(world.rng, player.currentTown.dungeonLayout)
+	}
+}
+
+/*
+Passwords are hashed with PBKDF2 (HMAC-SHA256) and a random salt
+Hashes are stored as "pbkdf2-sha256$iterations$salt$hash" so the number of iterations can be raised later
+*/
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, SALT_LENGTH)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	hash := pbkdf2([]byte(password), salt, HASH_ITERATIONS, HASH_LENGTH)
+
+	return "pbkdf2-sha256$" + strconv.Itoa(HASH_ITERATIONS) + "$" + base64.RawStdEncoding.EncodeToString(salt) + "$" + base64.RawStdEncoding.EncodeToString(hash), nil
+}
+
+func checkPassword(password string, encoded string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	hash := pbkdf2([]byte(password), salt, iterations, len(expected))
+
+	return subtle.ConstantTimeCompare(hash, expected) == 1
+}
+
+// PBKDF2 as defined in RFC 8018 using HMAC-SHA256 as the pseudorandom function
+func pbkdf2(password []byte, salt []byte, iterations int, keyLength int) []byte {
+	prf := hmac.New(sha256.New, password)
+	blocks := (keyLength + prf.Size() - 1) / prf.Size()
+
+	key := make([]byte, 0, blocks*prf.Size())
+	blockIndex := make([]byte, 4)
+
+	for block := 1; block <= blocks; block++ {
+		binary.BigEndian.PutUint32(blockIndex, uint32(block))
+
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(blockIndex)
+		u := prf.Sum(nil)
+
+		t := make([]byte, len(u))
+		copy(t, u)
+
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+
+		key = append(key, t...)
+	}
+
+	return key[:keyLength]
+}
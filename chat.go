@@ -0,0 +1,225 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+const CHANNEL_HISTORY = 20 // Number of messages a channel remembers to replay to players who join it
+const SHOUT_CHANNEL = "shout"
+
+/*
+Channels are named rooms for chatting which players join and leave
+Membership is kept on each player so only the recent history is kept here
+*/
+type ChatChannel struct {
+	name    string
+	history []string
+}
+
+// Retrieve a channel by name, creating it if nobody has used it before
+func (world *World) getChannel(name string) *ChatChannel {
+	name = strings.ToLower(name)
+
+	channel, ok := world.channels[name]
+	if !ok {
+		channel = &ChatChannel{name: name}
+		world.channels[name] = channel
+	}
+
+	return channel
+}
+
+func (channel *ChatChannel) remember(message string) {
+	channel.history = append(channel.history, message)
+	if len(channel.history) > CHANNEL_HISTORY {
+		channel.history = channel.history[len(channel.history)-CHANNEL_HISTORY:]
+	}
+}
+
+// Deliver a message from another player unless they are being ignored
+func (player *Player) receive(sender *Player, message string) {
+	if player.ignored[strings.ToLower(sender.name)] {
+		return
+	}
+
+	player.write(message)
+}
+
+/*
+Signature `say {message}`
+Speak to every player within the same town
+*/
+func (player *Player) say(modifiers []string) {
+	message := strings.Join(modifiers, " ")
+
+	for _, other := range player.otherPlayersInTown() {
+		other.receive(player, player.name+" says: "+message)
+	}
+
+	player.write("You say: " + message)
+}
+
+/*
+Signature `tell {player} {message}`
+Whisper to a single player wherever they are
+*/
+func (player *Player) tell(modifiers []string) {
+	recipient := getWorldInstance().sessions.find(modifiers[0])
+	if recipient == nil {
+		player.displayError("Nobody called " + modifiers[0] + " is among us")
+		return
+	}
+
+	message := strings.Join(modifiers[1:], " ")
+
+	recipient.receive(player, player.name+" tells you: "+message)
+	player.write("You tell " + recipient.name + ": " + message)
+}
+
+/*
+Signature `shout {message}`
+Speak to every player within the world (unless they have muted shouts)
+*/
+func (player *Player) shout(modifiers []string) {
+	message := strings.Join(modifiers, " ")
+
+	for _, other := range getWorldInstance().sessions.players() {
+		if other != player && !other.muted[SHOUT_CHANNEL] {
+			other.receive(player, player.name+" shouts: "+message)
+		}
+	}
+
+	player.write("You shout: " + message)
+}
+
+/*
+Signature `join {channel}`
+Join a chat channel and catch up on its recent messages
+*/
+func (player *Player) join(modifiers []string) {
+	name := strings.ToLower(modifiers[0])
+	if name == SHOUT_CHANNEL {
+		player.displayError("Everyone hears shouts already")
+		return
+	}
+
+	if player.channels[name] {
+		player.displayError("You are already within " + name)
+		return
+	}
+
+	player.channels[name] = true
+	channel := getWorldInstance().getChannel(name)
+
+	player.writeCompact("Joined " + name)
+	for _, message := range channel.history {
+		player.writeCompact(message)
+	}
+	player.writeCompact("")
+}
+
+/*
+Signature `leave {channel}`
+Leave a chat channel
+*/
+func (player *Player) leave(modifiers []string) {
+	name := strings.ToLower(modifiers[0])
+	if !player.channels[name] {
+		player.displayError("You are not within " + name)
+		return
+	}
+
+	delete(player.channels, name)
+	player.write("Left " + name)
+}
+
+/*
+Signature `chat {channel} {message}`
+Speak to every player within a channel
+*/
+func (player *Player) chat(modifiers []string) {
+	name := strings.ToLower(modifiers[0])
+	if !player.channels[name] {
+		player.displayError("You must join " + name + " before chatting within it")
+		return
+	}
+
+	message := "[" + name + "] " + player.name + ": " + strings.Join(modifiers[1:], " ")
+	getWorldInstance().getChannel(name).remember(message)
+
+	for _, other := range getWorldInstance().sessions.players() {
+		if other.channels[name] && !other.muted[name] {
+			other.receive(player, message)
+		}
+	}
+}
+
+/*
+Signature `channels`
+Lists the channels a player has joined and the ones other players are using
+*/
+func (player *Player) listChannels(modifiers []string) {
+	player.writeCompact("Joined: " + strings.Join(sortedKeys(player.channels), ", "))
+	player.writeCompact("Muted: " + strings.Join(sortedKeys(player.muted), ", "))
+	player.write("Available: " + strings.Join(sortedKeys(getWorldInstance().channels), ", "))
+}
+
+/*
+Signature `mute {channel}`
+Stop hearing a channel (or shouts) without leaving it
+*/
+func (player *Player) mute(modifiers []string) {
+	name := strings.ToLower(modifiers[0])
+	player.muted[name] = true
+	player.write("Muted " + name)
+}
+
+/*
+Signature `unmute {channel}`
+*/
+func (player *Player) unmute(modifiers []string) {
+	name := strings.ToLower(modifiers[0])
+	if !player.muted[name] {
+		player.displayError(name + " is not muted")
+		return
+	}
+
+	delete(player.muted, name)
+	player.write("Unmuted " + name)
+}
+
+/*
+Signature `ignore {player}`
+Stop hearing anything a player says, tells or shouts
+*/
+func (player *Player) ignore(modifiers []string) {
+	name := strings.ToLower(modifiers[0])
+	if name == strings.ToLower(player.name) {
+		player.displayError("You cannot ignore yourself")
+		return
+	}
+
+	player.ignored[name] = true
+	player.write("Ignoring " + modifiers[0])
+}
+
+/*
+Signature `unignore {player}`
+*/
+func (player *Player) unignore(modifiers []string) {
+	name := strings.ToLower(modifiers[0])
+	if !player.ignored[name] {
+		player.displayError("You are not ignoring " + modifiers[0])
+		return
+	}
+
+	delete(player.ignored, name)
+	player.write("No longer ignoring " + modifiers[0])
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := GetKeys(m)
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+const FLEE_CHANCE = 50 // Percentage chance of escaping a fight
+
+/*
+Combat is a fight between a player and an enemy which is played out a round at a time
+Each round the player picks an action and then the enemy strikes back
+*/
+type Combat struct {
+	enemy     *Event
+	defending bool       // Whether the player is bracing themselves against the next strike
+	attacks   [][]string // Descriptions of minimal, moderate and major attacks
+	responses [][]string // Descriptions of how a victim reacts to each level of attack
+}
+
+func (player *Player) startCombat(enemy *Event) {
+	combat := new(Combat)
+	combat.enemy = enemy
+
+	// Get appropriate data
+	for _, level := range []string{"minimal", "moderate", "major"} {
+		attacks, _ := os.ReadFile(config.dataPath("attack/" + level + ".txt"))
+		responses, _ := os.ReadFile(config.dataPath("attackResponse/" + level + ".txt"))
+
+		combat.attacks = append(combat.attacks, strings.Split(string(attacks), "\n"))
+		combat.responses = append(combat.responses, strings.Split(string(responses), "\n"))
+	}
+
+	player.combat = combat
+	player.options = map[string]func(modifiers []string){
+		"attack": player.attack,
+		"defend": player.defend,
+		"flee":   player.flee,
+		"use":    player.use,
+		"help":   player.combatHelp,
+	}
+
+	player.write(enemy.name + " stands before you (health " + strconv.Itoa(enemy.health) + ", attack " + strconv.Itoa(enemy.attack) + ", defense " + strconv.Itoa(enemy.defense) + ")")
+	player.combatHelp(nil)
+}
+
+func (player *Player) endCombat() {
+	player.combat = nil
+	player.options = nil
+}
+
+/*
+Signature `attack`
+Strike the enemy with whatever is equipped
+*/
+func (player *Player) attack(modifiers []string) {
+	if !player.isEnemyPresent() {
+		return
+	}
+
+	enemy := player.combat.enemy
+	rng := getWorldInstance().rng
+
+	damage := randNumInRange(rng, 2, 8) - enemy.defense
+	if player.weapon != nil {
+		damage += player.weapon.stats().Damage
+	}
+	if damage < 1 {
+		damage = 1
+	}
+
+	enemy.health -= damage
+	player.describeAttack(player.name, enemy.name, player.weaponName(), damage)
+
+	if enemy.health <= 0 {
+		player.winCombat()
+		return
+	}
+
+	player.enemyTurn()
+}
+
+/*
+Signature `defend`
+Brace against the enemy's next strike halving its damage
+*/
+func (player *Player) defend(modifiers []string) {
+	if !player.isEnemyPresent() {
+		return
+	}
+
+	player.combat.defending = true
+	player.writeCompact("You brace yourself")
+	player.enemyTurn()
+}
+
+/*
+Signature `flee`
+Attempt to escape the fight - if it fails the enemy strikes
+*/
+func (player *Player) flee(modifiers []string) {
+	if !player.isEnemyPresent() {
+		return
+	}
+
+	if getWorldInstance().rng.Intn(100) < FLEE_CHANCE {
+		player.write("You escape from " + player.combat.enemy.name)
+		player.endCombat()
+		return
+	}
+
+	player.writeCompact("You fail to escape")
+	player.enemyTurn()
+}
+
+/*
+Signature `use {item}`
+Eat food from the inventory mid fight
+*/
+func (player *Player) use(modifiers []string) {
+	if len(modifiers) < 1 {
+		player.displayError("")
+		return
+	}
+
+	if !player.isEnemyPresent() {
+		return
+	}
+
+	eaten := player.eatItem(strings.Join(modifiers, " "), func(name string) {
+		player.use([]string{name})
+	})
+
+	if eaten {
+		player.enemyTurn()
+	}
+}
+
+func (player *Player) combatHelp(modifiers []string) {
+	player.writeCompact("You are fighting - listed below are the actions you can undertake")
+	player.writeCompact("attack")
+	player.writeCompact("defend")
+	player.writeCompact("flee")
+	player.write("use {item}")
+}
+
+// Another player may have slain the enemy whilst this player was deciding what to do
+func (player *Player) isEnemyPresent() bool {
+	enemyIndex, _ := Find(player.currentTown.events, func(event *Event) bool {
+		return event == player.combat.enemy
+	})
+
+	if enemyIndex < 0 {
+		player.write(player.combat.enemy.name + " has already been slain")
+		player.endCombat()
+		return false
+	}
+
+	return true
+}
+
+func (player *Player) enemyTurn() {
+	enemy := player.combat.enemy
+
+	damage := randNumInRange(getWorldInstance().rng, 1, enemy.attack+1)
+	if player.armour != nil {
+		damage -= player.armour.stats().Defense
+	}
+	if player.combat.defending {
+		damage /= 2
+	}
+	if damage < 0 {
+		damage = 0
+	}
+
+	player.combat.defending = false
+	player.health -= damage
+	player.describeAttack(enemy.name, "you", "claws", damage)
+
+	if player.health <= 0 {
+		player.write("You collapse before " + enemy.name)
+		player.die()
+		return
+	}
+
+	player.write("Your health: " + strconv.Itoa(player.health) + " - " + enemy.name + "'s health: " + strconv.Itoa(enemy.health))
+}
+
+// The enemy is removed from the town and the player rewarded with gold and perhaps an item
+func (player *Player) winCombat() {
+	enemy := player.combat.enemy
+	rng := getWorldInstance().rng
+
+	player.write("You have slain " + enemy.name)
+
+	enemyIndex, _ := Find(player.currentTown.events, func(event *Event) bool {
+		return event == enemy
+	})
+	player.currentTown.events = RemoveAtIndex(player.currentTown.events, enemyIndex)
+
+	gold := randNumInRange(rng, 5, 26)
+	player.gold += gold
+	player.writeCompact("You loot " + strconv.Itoa(gold) + " gold coins")
+
+	if rng.Intn(2) == 0 {
+		if definition := itemCatalog.random(rng, Random, Weapon, Armour); definition != nil {
+			item := itemCatalog.newItem(definition, *player.coordinates)
+			player.addToInventory(item)
+			player.writeCompact("You find " + item.description + " upon the body")
+		}
+	}
+
+	player.writeCompact("")
+	player.endCombat()
+}
+
+// Describe an attack using the attack and response data files (the level of attack depends on the damage dealt)
+func (player *Player) describeAttack(attacker string, victim string, weapon string, damage int) {
+	level := 0
+	if damage >= 8 {
+		level = 2
+	} else if damage >= 4 {
+		level = 1
+	}
+
+	rng := getWorldInstance().rng
+	attacks := player.combat.attacks[level]
+	responses := player.combat.responses[level]
+
+	description := strings.NewReplacer("victim", victim, "weapon", weapon).Replace(attacks[rng.Intn(len(attacks))])
+
+	player.writeCompact(attacker + " " + description + " (" + strconv.Itoa(damage) + " damage)")
+	player.writeCompact(responses[rng.Intn(len(responses))])
+}
+
+func (player *Player) weaponName() string {
+	if player.weapon == nil {
+		return "fists"
+	}
+	return player.weapon.description
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type ArgumentKind int
+
+// Kind of argument decides how many words it takes and which are allowed
+const (
+	Word   ArgumentKind = iota // A single word
+	Number                     // A single whole number
+	Text                       // Every remaining word (such as an item name or a message)
+)
+
+type Argument struct {
+	name     string
+	kind     ArgumentKind
+	optional bool
+	fallback string   // Value used when an optional argument is left out (nothing is passed if empty)
+	choices  []string // Words which are allowed (any are if empty)
+}
+
+/*
+Commands are everything a player can enter outside of trading or combat
+Each one describes its arguments so they can be checked before it is run
+*/
+type Command struct {
+	name      string
+	aliases   map[string][]string // Shortcuts for the command along with any arguments they fill in
+	arguments []Argument
+	help      string
+	run       func(player *Player, modifiers []string)
+}
+
+// Show how a command is entered such as `move {direction} [distance]`
+func (command *Command) usage() string {
+	usage := command.name
+
+	for _, argument := range command.arguments {
+		if argument.optional {
+			usage += " [" + argument.name + "]"
+		} else {
+			usage += " {" + argument.name + "}"
+		}
+	}
+
+	return usage
+}
+
+// Check the words given to a command against its arguments filling in any which were left out
+func (command *Command) parse(modifiers []string) ([]string, bool) {
+	var parsed []string
+
+	for i, argument := range command.arguments {
+		if i >= len(modifiers) {
+			if !argument.optional {
+				return nil, false
+			}
+			if argument.fallback != "" {
+				parsed = append(parsed, argument.fallback)
+			}
+			continue
+		}
+
+		if argument.kind == Text {
+			return append(parsed, modifiers[i:]...), true
+		}
+
+		value := modifiers[i]
+		if argument.kind == Number && (value == "" || !isInt(value)) {
+			return nil, false
+		}
+
+		if len(argument.choices) > 0 {
+			value = strings.ToLower(value)
+			if !Contains(argument.choices, value) {
+				return nil, false
+			}
+		}
+
+		parsed = append(parsed, value)
+	}
+
+	if len(modifiers) > len(command.arguments) {
+		return nil, false
+	}
+
+	return parsed, true
+}
+
+type alias struct {
+	command *Command
+	preset  []string
+}
+
+type CommandRegistry struct {
+	commands map[string]*Command
+	aliases  map[string]alias
+}
+
+var commandRegistry = NewCommandRegistry()
+
+func NewCommandRegistry() *CommandRegistry {
+	registry := new(CommandRegistry)
+	registry.commands = make(map[string]*Command)
+	registry.aliases = make(map[string]alias)
+	return registry
+}
+
+func (registry *CommandRegistry) register(command *Command) {
+	registry.commands[command.name] = command
+	for name, preset := range command.aliases {
+		registry.aliases[name] = alias{command, preset}
+	}
+}
+
+// Look up a command by name or alias returning any arguments the alias fills in (nil if there is no such command)
+func (registry *CommandRegistry) find(name string) (*Command, []string) {
+	if command, ok := registry.commands[name]; ok {
+		return command, nil
+	}
+	if alias, ok := registry.aliases[name]; ok {
+		return alias.command, alias.preset
+	}
+	return nil, nil
+}
+
+// Every command in alphabetical order
+func (registry *CommandRegistry) sorted() []*Command {
+	commands := GetValues(registry.commands)
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].name < commands[j].name
+	})
+	return commands
+}
+
+// Validate and run a command a player entered
+func (registry *CommandRegistry) execute(player *Player, parsedInput []string) {
+	if len(parsedInput) == 0 {
+		return
+	}
+
+	command, preset := registry.find(parsedInput[0])
+	if command == nil {
+		player.displayError("Unknown command - enter help to list them")
+		return
+	}
+
+	modifiers, ok := command.parse(append(append([]string(nil), preset...), parsedInput[1:]...))
+	if !ok {
+		player.displayError("Usage: " + command.usage())
+		return
+	}
+
+	command.run(player, modifiers)
+}
+
+/*
+Signature `help [command]`
+Lists every command or explains a single one
+*/
+func (player *Player) help(modifiers []string) {
+	if len(modifiers) > 0 {
+		command, _ := commandRegistry.find(strings.ToLower(modifiers[0]))
+		if command == nil {
+			player.displayError("There is no command called " + modifiers[0])
+			return
+		}
+
+		player.writeCompact(command.usage())
+
+		if len(command.aliases) > 0 {
+			var aliases []string
+			for _, name := range sortedKeys(command.aliases) {
+				aliases = append(aliases, strings.TrimSpace(name+" ("+strings.Join(append([]string{command.name}, command.aliases[name]...), " ")+")"))
+			}
+			player.writeCompact("Aliases: " + strings.Join(aliases, ", "))
+		}
+
+		player.write(command.help)
+		return
+	}
+
+	player.writeCompact("Here lies the possible combinations one can enter")
+
+	for _, command := range commandRegistry.sorted() {
+		player.writeCompact(fmt.Sprintf("%-28s %s", command.usage(), command.help))
+	}
+
+	player.write("Enter help {command} to learn more about one")
+}
+
+// Directions towns adjoin one another in (in the same order as adjacentTowns)
+var directionNames = []string{"north", "south", "east", "west"}
+
+func init() {
+	item := Argument{name: "item", kind: Text}
+	channel := Argument{name: "channel", kind: Word}
+	message := Argument{name: "message", kind: Text}
+	name := Argument{name: "player", kind: Word}
+	target := Argument{name: "target", kind: Text}
+
+	for _, command := range []*Command{
+		{
+			name: "move",
+			aliases: map[string][]string{
+				"n": {"north"},
+				"s": {"south"},
+				"e": {"east"},
+				"w": {"west"},
+			},
+			arguments: []Argument{
+				{name: "direction", kind: Word, choices: sortedKeys(directions)},
+				{name: "distance", kind: Number, optional: true, fallback: "1"},
+			},
+			help: "Walk in a direction until you have gone the distance or hit a wall",
+			run:  (*Player).move,
+		},
+		{name: "scan", arguments: []Argument{{name: "distance", kind: Number}}, help: "List everything within a distance of you", run: (*Player).scan},
+		{name: "locate", arguments: []Argument{target}, help: "Find the path to the nearest item, NPC, enemy, hotspot, gate, player or coordinates", run: (*Player).locate},
+		{name: "goto", arguments: []Argument{target}, help: "Walk to whatever locate would find", run: (*Player).goTo},
+		{name: "look", aliases: map[string][]string{"l": nil}, help: "Describe the town and whatever is where you stand", run: (*Player).look},
+		{name: "map", help: "Draw the town around you", run: (*Player).printMap},
+		{name: "jump", arguments: []Argument{{name: "direction", kind: Word, choices: directionNames}}, help: "Travel through the gate you stand upon to the neighbouring town", run: (*Player).jump},
+		{name: "route", arguments: []Argument{{name: "town", kind: Text}}, help: "List the jumps needed to reach a town", run: (*Player).route},
+		{name: "travel", arguments: []Argument{{name: "town", kind: Text}}, help: "Make every jump needed to reach a town", run: (*Player).travel},
+		{name: "pickup", arguments: []Argument{item}, help: "Pick up an item where you stand", run: (*Player).pickup},
+		{name: "drop", arguments: []Argument{item}, help: "Drop an item where you stand", run: (*Player).drop},
+		{name: "inventory", aliases: map[string][]string{"i": nil}, help: "List what you are carrying", run: (*Player).inventoryList},
+		{name: "combine", arguments: []Argument{{name: "items", kind: Text}}, help: "Craft a new item from items you are carrying", run: (*Player).combine},
+		{name: "recipes", help: "List the recipes you have discovered", run: (*Player).listRecipes},
+		{name: "equip", arguments: []Argument{item}, help: "Wield a weapon or wear armour", run: (*Player).equip},
+		{name: "unequip", arguments: []Argument{item}, help: "Stop using a weapon or armour", run: (*Player).unequip},
+		{name: "eat", arguments: []Argument{item}, help: "Eat food to restore your health", run: (*Player).eat},
+		{name: "loot", help: "Recover your belongings from your corpse", run: (*Player).loot},
+		{name: "stats", help: "Show your health, gold and equipment", run: (*Player).viewStats},
+		{name: "who", help: "List the players who are logged in", run: (*Player).who},
+		{name: "say", arguments: []Argument{message}, help: "Speak to everyone within your town", run: (*Player).say},
+		{name: "tell", arguments: []Argument{name, message}, help: "Whisper to a single player", run: (*Player).tell},
+		{name: "shout", arguments: []Argument{message}, help: "Speak to everyone within the world", run: (*Player).shout},
+		{name: "join", arguments: []Argument{channel}, help: "Join a chat channel", run: (*Player).join},
+		{name: "leave", arguments: []Argument{channel}, help: "Leave a chat channel", run: (*Player).leave},
+		{name: "chat", arguments: []Argument{channel, message}, help: "Speak to everyone within a channel", run: (*Player).chat},
+		{name: "channels", help: "List the chat channels", run: (*Player).listChannels},
+		{name: "mute", arguments: []Argument{channel}, help: "Stop hearing a channel (or shout)", run: (*Player).mute},
+		{name: "unmute", arguments: []Argument{channel}, help: "Hear a muted channel again", run: (*Player).unmute},
+		{name: "ignore", arguments: []Argument{name}, help: "Stop hearing a player", run: (*Player).ignore},
+		{name: "unignore", arguments: []Argument{name}, help: "Hear an ignored player again", run: (*Player).unignore},
+		{name: "help", arguments: []Argument{{name: "command", kind: Word, optional: true}}, help: "List the commands or explain one", run: (*Player).help},
+		{name: "quit", help: "Leave the game", run: (*Player).quit},
+	} {
+		commandRegistry.register(command)
+	}
+}
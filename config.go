@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+Config holds every setting of the server
+Values are taken from the defaults, then the config file (if any) and finally the command line flags
+*/
+type Config struct {
+	Address           string   `json:"address"`           // Address the server listens on
+	DataDirectory     string   `json:"dataDirectory"`     // Directory containing the data files (towns, items, enemies...)
+	WorldFile         string   `json:"worldFile"`         // File the world is loaded from and saved to
+	AccountsDirectory string   `json:"accountsDirectory"` // Directory player accounts are saved in
+	Autosave          Duration `json:"autosave"`          // How often the world is saved (0 to only save on shutdown)
+	Seed              int64    `json:"seed"`              // Seed used to generate a new world (0 picks one at random)
+	Width             int      `json:"width"`             // Width of map
+	Height            int      `json:"height"`            // Height of map
+	MaxTunnels        int      `json:"maxTunnels"`        // Greatest number of turns algorithm can make
+	MaxTunnelLength   int      `json:"maxTunnelLength"`   // Greatest length of each tunnel the algorithm will choose before making a turn
+	MinTowns          int      `json:"minTowns"`          // Least number of towns generated
+	MaxTowns          int      `json:"maxTowns"`          // Greatest number of towns generated
+	StartingHealth    int      `json:"startingHealth"`    // Health of a new player
+	StartingGold      int      `json:"startingGold"`      // Gold coins of a new player
+	RespawnTown       string   `json:"respawnTown"`       // Town players return to when they die ("" for the first town)
+	DeathGoldPenalty  int      `json:"deathGoldPenalty"`  // Percentage of gold lost upon death
+	CorpseDecay       Duration `json:"corpseDecay"`       // How long a corpse remains before its items are lost
+}
+
+// Duration is written as a string such as "5m" or "30s" within the config file
+type Duration struct {
+	time.Duration
+}
+
+func (duration *Duration) UnmarshalJSON(b []byte) error {
+	var text string
+	if err := json.Unmarshal(b, &text); err != nil {
+		return errors.New("durations must be strings such as \"5m\"")
+	}
+
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+
+	duration.Duration = parsed
+	return nil
+}
+
+func (duration Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(duration.String())
+}
+
+// Data files which must exist within the data directory
+var requiredDataFiles = []string{
+	"towns.txt",
+	"townDescription.txt",
+	"items.json",
+	"food.json",
+	"recipes.json",
+	"npcNames.txt",
+	"enemies.txt",
+	"attack/minimal.txt",
+	"attack/moderate.txt",
+	"attack/major.txt",
+	"attackResponse/minimal.txt",
+	"attackResponse/moderate.txt",
+	"attackResponse/major.txt",
+}
+
+var config = defaultConfig()
+
+func defaultConfig() *Config {
+	c := new(Config)
+	c.Address = "localhost:5000"
+	c.DataDirectory = "data"
+	c.WorldFile = "saves/world.json"
+	c.AccountsDirectory = "saves/players"
+	c.Autosave = Duration{5 * time.Minute}
+	c.Width = 30
+	c.Height = 15
+	c.MaxTunnels = 50
+	c.MaxTunnelLength = 30
+	c.MinTowns = 2
+	c.MaxTowns = 5
+	c.StartingHealth = 100
+	c.StartingGold = 100
+	c.DeathGoldPenalty = 10
+	c.CorpseDecay = Duration{10 * time.Minute}
+	return c
+}
+
+/*
+Build the configuration from the command line arguments
+The flags are parsed twice - once to find the config file and again so they take precedence over it
+*/
+func loadConfig(args []string) (*Config, error) {
+	c := defaultConfig()
+
+	flags := flag.NewFlagSet("gud", flag.ContinueOnError)
+	configFile := flags.String("config", "", "JSON file to read the configuration from")
+	flags.StringVar(&c.Address, "address", c.Address, "address the server listens on")
+	flags.StringVar(&c.DataDirectory, "data", c.DataDirectory, "directory containing the data files")
+	flags.StringVar(&c.WorldFile, "world", c.WorldFile, "file the world is loaded from and saved to")
+	flags.StringVar(&c.AccountsDirectory, "accounts", c.AccountsDirectory, "directory player accounts are saved in")
+	flags.DurationVar(&c.Autosave.Duration, "autosave", c.Autosave.Duration, "how often the world is saved (0 to only save on shutdown)")
+	flags.Int64Var(&c.Seed, "seed", c.Seed, "seed used to generate a new world (0 picks one at random)")
+	flags.IntVar(&c.Width, "width", c.Width, "width of each town's map")
+	flags.IntVar(&c.Height, "height", c.Height, "height of each town's map")
+	flags.IntVar(&c.MaxTunnels, "tunnels", c.MaxTunnels, "greatest number of tunnels dug into each town")
+	flags.IntVar(&c.MaxTunnelLength, "tunnel-length", c.MaxTunnelLength, "greatest length of each tunnel")
+	flags.IntVar(&c.MinTowns, "min-towns", c.MinTowns, "least number of towns generated")
+	flags.IntVar(&c.MaxTowns, "max-towns", c.MaxTowns, "greatest number of towns generated")
+	flags.IntVar(&c.StartingHealth, "health", c.StartingHealth, "health of a new player")
+	flags.IntVar(&c.StartingGold, "gold", c.StartingGold, "gold coins of a new player")
+	flags.StringVar(&c.RespawnTown, "respawn", c.RespawnTown, "town players return to when they die (empty for the first town)")
+	flags.IntVar(&c.DeathGoldPenalty, "death-penalty", c.DeathGoldPenalty, "percentage of gold lost upon death")
+	flags.DurationVar(&c.CorpseDecay.Duration, "corpse-decay", c.CorpseDecay.Duration, "how long a corpse remains before its items are lost")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configFile != "" {
+		file, err := os.Open(*configFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		// Misspelt settings would otherwise be silently ignored
+		decoder := json.NewDecoder(file)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(c); err != nil {
+			return nil, fmt.Errorf("%s: %w", *configFile, err)
+		}
+
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+
+	return c, c.validate()
+}
+
+// Check every setting is usable, reporting all of the problems at once
+func (c *Config) validate() error {
+	var problems []string
+
+	if c.Address == "" {
+		problems = append(problems, "address must not be empty")
+	}
+
+	if c.WorldFile == "" {
+		problems = append(problems, "worldFile must not be empty")
+	}
+
+	if c.AccountsDirectory == "" {
+		problems = append(problems, "accountsDirectory must not be empty")
+	}
+
+	if c.Autosave.Duration < 0 {
+		problems = append(problems, "autosave must not be negative")
+	}
+
+	if c.Width < 5 || c.Width > 1000 {
+		problems = append(problems, "width must be between 5 and 1000")
+	}
+
+	if c.Height < 5 || c.Height > 1000 {
+		problems = append(problems, "height must be between 5 and 1000")
+	}
+
+	if c.MaxTunnels < 1 {
+		problems = append(problems, "maxTunnels must be at least 1")
+	}
+
+	if c.MaxTunnelLength < 1 {
+		problems = append(problems, "maxTunnelLength must be at least 1")
+	}
+
+	if c.MinTowns < 1 {
+		problems = append(problems, "minTowns must be at least 1")
+	}
+
+	if c.MaxTowns < c.MinTowns {
+		problems = append(problems, "maxTowns must not be less than minTowns")
+	}
+
+	if c.StartingHealth < 1 {
+		problems = append(problems, "startingHealth must be at least 1")
+	}
+
+	if c.StartingGold < 0 {
+		problems = append(problems, "startingGold must not be negative")
+	}
+
+	if c.DeathGoldPenalty < 0 || c.DeathGoldPenalty > 100 {
+		problems = append(problems, "deathGoldPenalty must be between 0 and 100")
+	}
+
+	if c.CorpseDecay.Duration <= 0 {
+		problems = append(problems, "corpseDecay must be positive")
+	}
+
+	for _, file := range requiredDataFiles {
+		if _, err := os.Stat(c.dataPath(file)); err != nil {
+			problems = append(problems, "data file "+c.dataPath(file)+" is missing")
+		}
+	}
+
+	// A town name is always left over when picking names so there must be more names than towns
+	if townNames, err := os.ReadFile(c.dataPath("towns.txt")); err == nil {
+		if count := len(strings.Split(string(townNames), "\n")); c.MaxTowns >= count {
+			problems = append(problems, fmt.Sprintf("maxTowns must be less than the %d names in %s", count, c.dataPath("towns.txt")))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// Location of a file within the data directory
+func (c *Config) dataPath(name string) string {
+	return filepath.Join(c.DataDirectory, name)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+/*
+Corpses are left behind when a player dies and hold everything they were carrying
+Only their owner may loot them and they crumble away (along with their items) after config.CorpseDecay
+*/
+type Corpse struct {
+	owner       string
+	items       []Item
+	coordinates Point
+	decaysAt    time.Time
+}
+
+/*
+Called whenever a player's health falls to zero
+Their inventory is left in a corpse where they fell and they return to the respawn town poorer
+*/
+func (player *Player) die() {
+	town := player.currentTown
+
+	corpse := new(Corpse)
+	corpse.owner = player.name
+	corpse.items = player.inventory
+	corpse.coordinates = *NewPoint(player.coordinates.x, player.coordinates.y)
+	corpse.decaysAt = time.Now().Add(config.CorpseDecay.Duration)
+	town.corpses = append(town.corpses, corpse)
+
+	player.inventory = make([]Item, 0)
+	player.armour = nil
+	player.weapon = nil
+	player.endCombat()
+	player.stopWalking()
+
+	penalty := player.gold * config.DeathGoldPenalty / 100
+	player.gold -= penalty
+
+	for _, other := range player.otherPlayersInTown() {
+		other.write(player.name + " has fallen at " + corpse.coordinates.format())
+	}
+
+	player.write("You have perished and lost " + strconv.Itoa(penalty) + " gold coins. Your belongings lie at " + corpse.coordinates.format() + " in " + town.name + " - return within " + formatDuration(config.CorpseDecay.Duration) + " to loot them")
+
+	player.respawn()
+}
+
+// Return a player to the respawn town with full health
+func (player *Player) respawn() {
+	world := getWorldInstance()
+
+	town := world.findTown(config.RespawnTown)
+	if town == nil {
+		town = world.towns[0]
+	}
+
+	for _, other := range player.otherPlayersInTown() {
+		other.write(player.name + " has left for " + town.name)
+	}
+
+	player.currentTown = town
+	player.coordinates = NewPoint(config.Width/2, config.Height/2)
+	player.health = player.maxHealth()
+	player.effects = nil
+
+	for _, other := range player.otherPlayersInTown() {
+		other.write(player.name + " has been revived within " + town.name)
+	}
+
+	player.write("You awaken within " + town.name + " - " + town.description)
+	player.listRoutes()
+}
+
+/*
+Signature `loot`
+Recover everything from your corpse whilst standing upon it
+*/
+func (player *Player) loot(modifiers []string) {
+	corpseIndex, corpse := Find(player.currentTown.corpses, func(corpse *Corpse) bool {
+		return corpse.owner == player.name && corpse.coordinates.x == player.coordinates.x && corpse.coordinates.y == player.coordinates.y
+	})
+
+	if corpseIndex < 0 {
+		player.displayError("There is no corpse of yours here")
+		return
+	}
+
+	for _, item := range corpse.items {
+		player.addToInventory(item)
+	}
+	player.currentTown.corpses = RemoveAtIndex(player.currentTown.corpses, corpseIndex)
+
+	player.write("You recover " + strconv.Itoa(len(corpse.items)) + " items from your corpse")
+}
+
+// Remove corpses which have decayed letting their owners know (must be run by the game loop)
+func (world *World) decayCorpses(now time.Time) {
+	for _, town := range world.towns {
+		remaining := town.corpses[:0]
+
+		for _, corpse := range town.corpses {
+			if now.Before(corpse.decaysAt) {
+				remaining = append(remaining, corpse)
+				continue
+			}
+
+			world.sessions.message(corpse.owner, "Your corpse within "+town.name+" has crumbled to dust along with your belongings")
+		}
+
+		town.corpses = remaining
+	}
+}
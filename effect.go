@@ -0,0 +1,80 @@
+package main
+
+import "strconv"
+
+// Effects which food may leave upon whoever eats it
+const (
+	POISON       = "poison"
+	REGENERATION = "regeneration"
+)
+
+/*
+Status effects change a player's health a little every tick until they wear off
+Poison hurts and regeneration heals
+*/
+type StatusEffect struct {
+	name      string
+	amount    int // Health lost (poison) or gained (regeneration) each tick
+	remaining int // Ticks left before the effect wears off
+}
+
+// Start an effect from something which was eaten (eating more of it again restarts it)
+func (player *Player) addEffect(definition ItemDefinition) {
+	if definition.Effect == "" {
+		return
+	}
+
+	for _, effect := range player.effects {
+		if effect.name == definition.Effect {
+			if definition.EffectAmount > effect.amount {
+				effect.amount = definition.EffectAmount
+			}
+			effect.remaining = definition.EffectTicks
+			return
+		}
+	}
+
+	player.effects = append(player.effects, &StatusEffect{definition.Effect, definition.EffectAmount, definition.EffectTicks})
+
+	switch definition.Effect {
+	case POISON:
+		player.write("You feel poison coursing through you")
+	case REGENERATION:
+		player.write("You feel your wounds begin to close")
+	}
+}
+
+// Apply every effect upon a player for a single tick (must be run by the game loop)
+func (player *Player) applyEffects() {
+	remaining := player.effects[:0]
+
+	for _, effect := range player.effects {
+		switch effect.name {
+		case POISON:
+			player.health -= effect.amount
+		case REGENERATION:
+			player.health = clamp(player.health+effect.amount, 0, player.maxHealth())
+		}
+
+		effect.remaining--
+		if effect.remaining > 0 {
+			remaining = append(remaining, effect)
+		} else {
+			player.write("The " + effect.name + " has worn off - your health is " + strconv.Itoa(player.health))
+		}
+	}
+
+	player.effects = remaining
+
+	if player.health <= 0 {
+		player.write("The poison overwhelms you")
+		player.die()
+	}
+}
+
+// Run the effects upon every player who is logged in (must be run by the game loop)
+func (world *World) applyEffects() {
+	for _, player := range world.sessions.players() {
+		player.applyEffects()
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import "time"
+
+const TICK = time.Second // How often time advances within the world
+
+/*
+The game loop is the only goroutine which mutates the world
+Connections submit their commands to it and they are executed one after another so that
+actions such as pickup, drop, trading and combat are atomic across every player
+*/
+
+// Start processing commands which have been submitted by players
+func (world *World) run() {
+	for command := range world.commands {
+		command()
+	}
+}
+
+// Advance time within the world until the program exits
+func (world *World) clock() {
+	for now := range time.Tick(TICK) {
+		now := now
+		world.execute(func() {
+			world.tick(now)
+		})
+	}
+}
+
+// Everything which changes over time happens here (must be run by the game loop)
+func (world *World) tick(now time.Time) {
+	world.decayCorpses(now)
+	world.applyEffects()
+}
+
+// Send a message to every player who has logged in
+func (world *World) broadcast(text string) {
+	world.execute(func() {
+		for _, player := range world.sessions.players() {
+			player.write(text)
+		}
+	})
+}
+
+// Submit a command to the game loop and wait until it has been executed
+func (world *World) execute(command func()) {
+	done := make(chan struct{})
+
+	world.commands <- func() {
+		defer close(done)
+		command()
+	}
+
+	<-done
+}
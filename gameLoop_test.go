@@ -0,0 +1,177 @@
+package main
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+const (
+	TEST_SESSIONS   = 6
+	TEST_ITERATIONS = 200
+)
+
+// Run a function for several players at once each from a goroutine of its own as connections do
+func runSessions(t *testing.T, name string, session func(player *Player)) []*Player {
+	var players []*Player
+	for i := 0; i < TEST_SESSIONS; i++ {
+		players = append(players, newTestPlayer(t, name+strconv.Itoa(i)))
+	}
+
+	var wg sync.WaitGroup
+	for _, player := range players {
+		wg.Add(1)
+		go func(player *Player) {
+			defer wg.Done()
+			session(player)
+		}(player)
+	}
+	wg.Wait()
+
+	return players
+}
+
+func countItems(items []Item, id string) int {
+	count := 0
+	for _, item := range items {
+		if item.id == id {
+			count += item.quantity
+		}
+	}
+	return count
+}
+
+func TestConcurrentPickupAndDrop(t *testing.T) {
+	world := getWorldInstance()
+	town := world.towns[0]
+	centre := Point{config.Width / 2, config.Height / 2}
+
+	var before int
+	world.execute(func() {
+		for i := 0; i < TEST_SESSIONS; i++ {
+			town.items = append(town.items, itemCatalog.newItem(itemCatalog.get("torch"), centre))
+		}
+		before = countItems(town.items, "torch")
+	})
+
+	players := runSessions(t, "picker", func(player *Player) {
+		for i := 0; i < TEST_ITERATIONS; i++ {
+			world.execute(func() {
+				player.handleInput([]string{"pickup", "torch"})
+			})
+			world.execute(func() {
+				player.handleInput([]string{"drop", "torch"})
+			})
+		}
+	})
+
+	world.execute(func() {
+		after := countItems(town.items, "torch")
+		for _, player := range players {
+			after += countItems(player.inventory, "torch")
+		}
+
+		if after != before {
+			t.Errorf("expected %d torches after picking up and dropping them but found %d", before, after)
+		}
+	})
+}
+
+func TestConcurrentTrading(t *testing.T) {
+	world := getWorldInstance()
+
+	// Every player trades with the same vendor
+	var stock []Item
+	for i := 0; i < TEST_SESSIONS; i++ {
+		stock = append(stock, itemCatalog.newItem(itemCatalog.get("torch"), Point{}))
+	}
+
+	players := runSessions(t, "trader", func(player *Player) {
+		for i := 0; i < TEST_ITERATIONS; i++ {
+			world.execute(func() {
+				player.buyItem([]string{"torch"}, &stock)
+			})
+			world.execute(func() {
+				player.sellItem([]string{"torch"}, &stock)
+			})
+		}
+	})
+
+	world.execute(func() {
+		after := countItems(stock, "torch")
+		for _, player := range players {
+			after += countItems(player.inventory, "torch")
+
+			if player.gold < 0 {
+				t.Errorf("%s was left with %d gold", player.name, player.gold)
+			}
+		}
+
+		if after != TEST_SESSIONS {
+			t.Errorf("expected %d torches after trading them but found %d", TEST_SESSIONS, after)
+		}
+	})
+}
+
+func TestConcurrentCombat(t *testing.T) {
+	world := getWorldInstance()
+	town := world.towns[0]
+
+	enemy := NewEvent(Point{config.Width / 2, config.Height / 2}, Enemy, "Test Dummy")
+	enemy.health = 300
+	enemy.attack = 1
+
+	world.execute(func() {
+		town.events = append(town.events, enemy)
+	})
+
+	present := func() bool {
+		index, _ := Find(town.events, func(event *Event) bool {
+			return event == enemy
+		})
+		return index >= 0
+	}
+
+	var mutex sync.Mutex
+	victors := 0
+
+	runSessions(t, "fighter", func(player *Player) {
+		for {
+			finished := false
+
+			world.execute(func() {
+				if !present() {
+					finished = true
+					return
+				}
+
+				if player.combat == nil {
+					player.startCombat(enemy)
+				}
+
+				player.handleInput([]string{"attack"})
+
+				// Commands never overlap so only the attack which removed the enemy can see it vanish
+				if !present() {
+					mutex.Lock()
+					victors++
+					mutex.Unlock()
+				}
+			})
+
+			if finished {
+				return
+			}
+		}
+	})
+
+	if victors != 1 {
+		t.Errorf("expected the enemy to be slain once but it was slain %d times", victors)
+	}
+
+	world.execute(func() {
+		if present() {
+			t.Error("expected the slain enemy to be removed from the town")
+		}
+	})
+}
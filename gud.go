@@ -1,18 +1,15 @@
 package main
 
 import (
-	"bytes"
+	"flag"
 	"fmt"
-	"net"
+	"os"
+	"os/signal"
 	"regexp"
 	"strings"
+	"syscall"
 )
 
-const WIDTH = 30             // Width of map
-const HEIGHT = 15            // Height of map
-const MAX_TUNNELS = 50       // Greatest number of turns algorithm can make
-const MAX_TUNNEL_LENGTH = 30 // Greatest length of each tunnel the algorithm will choose before making a turn
-
 const BANNER = `
 ______   __    __  _______
 /      \ /  |  /  |/       \
@@ -25,90 +22,299 @@ $$    $$/ $$    $$/ $$    $$/
 $$$$$$/   $$$$$$/  $$$$$$$/
 `
 
-var nonAlphanumericRegex = regexp.MustCompile(`[^a-zA-Z0-9 ]+`)
+var nameRegex = regexp.MustCompile(`^[a-zA-Z0-9]{3,16}$`)
 
-func handleConnection(conn net.Conn) {
-	player := NewPlayer(NewPoint(15, 10), conn, "Example", getWorldInstance().towns[0])
+func handleConnection(conn *TelnetConn) {
+	// Tunnels are dug outwards from the centre of a town so it is always walkable
+	player := NewPlayer(NewPoint(config.Width/2, config.Height/2), conn, "Example", getWorldInstance().towns[0])
 
 	player.write(BANNER)
 
-	// Create new player and retrieve name
+	// Create new player or restore an existing one
 	player.write("Good day fellow union member!")
-	player.write("By what do you wish to be addressed by?")
-
-	nameBytes := make([]byte, 256)
-	_, _ = conn.Read(nameBytes)
-
-	nameStr := nonAlphanumericRegex.ReplaceAllString(string(nameBytes), "")
 
-	player.name = nameStr
-
-	// Dictionary of actions which players can undertake
-	var actions = map[string]func(modifiers []string){
-		"move":    player.move,
-		"scan":    player.scan,
-		"locate":  player.locate,
-		"pickup":  player.pickup,
-		"drop":    player.drop,
-		"combine": player.combine,
-		"stats":   player.viewStats,
-		"equip":   player.equip,
-		"unequip": player.unequip,
-		"quit":    player.quit,
-		"map":     player.printMap,
-		"jump":    player.jump,
-		"eat": 	   player.eat,
-		"help":    player.help,
+	if !login(player) {
+		player.conn.Close()
+		return
 	}
 
-	player.actions = actions
+	getWorldInstance().execute(func() {
+		getWorldInstance().sessions.add(player)
+	})
+
+	nameStr := player.name
 
 	player.write("Welcome to GUD! "+nameStr)
+	if terminalType := player.conn.getTerminalType(); terminalType != "" {
+		fmt.Println(nameStr, "joined using", terminalType)
+	}
 
 	player.write(player.currentTown.description)
 	player.listRoutes()
 
 	for {
 		// Parse commands a user enters
-		tmp := make([]byte, 256)
-		conn.Read(tmp)
+		line, err := player.readLine()
+		if err != nil {
+			disconnect(player)
+			return
+		}
 
-		// Parse input by changing all special
-		parsedInput := strings.Split(nonAlphanumericRegex.ReplaceAllString(string(bytes.Trim(tmp, "\x00")), ""), " ")
+		parsedInput, err := tokenize(line)
+		if err != nil {
+			player.displayError("Your input has an unterminated quote")
+			continue
+		}
 
-		for _, word := range parsedInput {
-			word = strings.ToLower(word)
+		// Blank lines (including those made only of invisible characters) are ignored
+		if len(parsedInput) == 0 {
+			continue
 		}
 
-		if ContainsKey(actions, parsedInput[0]) {
-			actions[parsedInput[0]](parsedInput[1:len(parsedInput)])
+		// Commands are executed by the game loop so they never race with other players
+		getWorldInstance().execute(func() {
+			getWorldInstance().sessions.touch(player)
+			player.handleInput(parsedInput)
+		})
+	}
+}
+
+/*
+Ask for a name and then either log the player into that account or create it
+Returns false if the connection is lost before the player has logged in
+*/
+func login(player *Player) bool {
+	for {
+		player.write("By what do you wish to be addressed by?")
+
+		line, err := player.readLine()
+		if err != nil {
+			return false
+		}
+
+		// Names are single words so they can be used with commands such as tell and ignore
+		name := strings.TrimSpace(line)
+		if !nameRegex.MatchString(name) {
+			player.displayError("Names must be between 3 and 16 letters or numbers")
+			continue
+		}
+
+		// Hold on to the name so nobody else can log into or create it at the same time
+		if !accountStore.claim(name) {
+			player.displayError(name + " is already among us - choose another name")
+			continue
+		}
+
+		var success bool
+		if accountStore.exists(name) {
+			success, err = loginAccount(player, name)
 		} else {
-			player.write("Unknown command")
+			success, err = createAccount(player, name)
+		}
+
+		if err != nil || !success {
+			accountStore.release(name)
+		}
+
+		if err != nil {
+			return false
+		}
+
+		if success {
+			return true
 		}
 	}
 }
 
-func startServer() {
-	port := "localhost:5000"
-	ln, err := net.Listen("tcp", port) // Create a new server
+// Check the password for an existing account (allowing a few attempts) and restore the player
+func loginAccount(player *Player, name string) (bool, error) {
+	for attempt := 0; attempt < 3; attempt++ {
+		password, err := readPassword(player, "Password:")
+		if err != nil {
+			return false, err
+		}
+
+		record, err := accountStore.load(name, password)
+		if err == errInvalidPassword {
+			player.displayError("Incorrect password")
+			continue
+		} else if err != nil {
+			fmt.Println("Failed to load account", name, err)
+			player.displayError("Your account could not be loaded")
+			return false, nil
+		}
+
+		getWorldInstance().execute(func() {
+			player.applyRecord(record, getWorldInstance())
+		})
+
+		return true, nil
+	}
+
+	player.displayError("Too many incorrect attempts")
+	return false, nil
+}
+
+// Create a new account for a name which has not been used before
+func createAccount(player *Player, name string) (bool, error) {
+	player.write("No union member goes by " + name + " - would you like to join? (yes/no)")
+
+	answer, err := player.readLine()
 	if err != nil {
-		panic(err)
+		return false, err
 	}
 
-	fmt.Println("GUD running on port " + port)
+	if strings.ToLower(strings.TrimSpace(answer)) != "yes" {
+		return false, nil
+	}
 
-	for {
-		conn, err := ln.Accept()
-		if err != nil {
-			panic(err)
-		}
+	password, err := readPassword(player, "Choose a password:")
+	if err != nil {
+		return false, err
+	}
 
-		go handleConnection(conn)
+	if len(password) < 4 {
+		player.displayError("Passwords must be at least 4 characters")
+		return false, nil
 	}
+
+	confirmation, err := readPassword(player, "Repeat your password:")
+	if err != nil {
+		return false, err
+	}
+
+	if password != confirmation {
+		player.displayError("Passwords do not match")
+		return false, nil
+	}
+
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		fmt.Println("Failed to hash password", err)
+		player.displayError("Your account could not be created")
+		return false, nil
+	}
+
+	var record *PlayerRecord
+	getWorldInstance().execute(func() {
+		player.name = name
+		player.passwordHash = passwordHash
+		record = player.toRecord()
+	})
+
+	if err := accountStore.save(record); err != nil {
+		fmt.Println("Failed to save account", name, err)
+		player.displayError("Your account could not be created")
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Read a line without the client echoing it back
+func readPassword(player *Player, prompt string) (string, error) {
+	player.conn.setEcho(false)
+	defer player.conn.setEcho(true)
+
+	player.writeCompact(prompt)
+	password, err := player.readLine()
+	player.writeCompact("")
+
+	return password, err
+}
+
+// Save a player who has left and free their name for the next connection
+func disconnect(player *Player) {
+	world := getWorldInstance()
+
+	// Saved alongside the world so an autosave which began beforehand cannot overwrite this newer record
+	world.saving.Lock()
+
+	var record *PlayerRecord
+	world.execute(func() {
+		world.sessions.remove(player)
+		player.stopWalking()
+		record = player.toRecord()
+	})
+
+	if err := accountStore.save(record); err != nil {
+		fmt.Println("Failed to save account", player.name, err)
+	}
+
+	world.saving.Unlock()
+
+	accountStore.release(player.name)
+	player.conn.Close()
 }
 
 func main() {
-	getWorldInstance()
+	loadedConfig, err := loadConfig(os.Args[1:])
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid configuration:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	config = loadedConfig
+
+	itemCatalog, err = LoadItemCatalog(config.dataPath("items.json"), config.dataPath("food.json"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid item definitions:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	recipeBook, err = LoadRecipeBook(config.dataPath("recipes.json"), itemCatalog)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid recipes:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	accountStore = NewAccountStore(config.AccountsDirectory)
+
+	if err := startWorld(); err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid world:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	world := getWorldInstance()
 	fmt.Println("Game loaded")
-	startServer()
+
+	if config.RespawnTown != "" && world.findTown(config.RespawnTown) == nil {
+		fmt.Fprintln(os.Stderr, "Invalid configuration:")
+		fmt.Fprintln(os.Stderr, "respawnTown "+config.RespawnTown+" is not a town within the world")
+		os.Exit(2)
+	}
+
+	if config.Autosave.Duration > 0 {
+		go world.autosave(config.WorldFile, config.Autosave.Duration)
+	}
+
+	server, err := NewServer(config.Address)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to start server:", err)
+		os.Exit(1)
+	}
+
+	// Stop accepting players when interrupted (a second interrupt exits immediately)
+	interrupts := make(chan os.Signal, 2)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupts
+		fmt.Println("Shutting down")
+		server.close()
+
+		<-interrupts
+		fmt.Println("Forced to exit without saving")
+		os.Exit(1)
+	}()
+
+	server.serve()
+
+	if err := server.shutdown(world); err != nil {
+		fmt.Println("Failed to save world:", err)
+		os.Exit(1)
+	}
 }
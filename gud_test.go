@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+)
+
+// Tests share a world generated from a fixed seed using the data files within the repository
+func TestMain(m *testing.M) {
+	config = defaultConfig()
+	config.WorldFile = ""
+	config.Autosave = Duration{}
+	config.Seed = 1
+
+	var err error
+	itemCatalog, err = LoadItemCatalog(config.dataPath("items.json"), config.dataPath("food.json"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid item definitions:", err)
+		os.Exit(2)
+	}
+
+	recipeBook, err = LoadRecipeBook(config.dataPath("recipes.json"), itemCatalog)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid recipes:", err)
+		os.Exit(2)
+	}
+
+	if err := startWorld(); err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid world:", err)
+		os.Exit(2)
+	}
+
+	os.Exit(m.Run())
+}
+
+/*
+Log a player into the shared world at the centre of the first town (everything written to them is thrown away)
+Within world.execute use t.Error rather than t.Fatal as stopping the goroutine would stop the game loop
+*/
+func newTestPlayer(t *testing.T, name string) *Player {
+	t.Helper()
+
+	world := getWorldInstance()
+	server, client := net.Pipe()
+	go io.Copy(io.Discard, client)
+
+	player := NewPlayer(NewPoint(config.Width/2, config.Height/2), NewTelnetConn(server), name, world.towns[0])
+	player.inventory = nil
+
+	world.execute(func() {
+		world.sessions.add(player)
+	})
+
+	t.Cleanup(func() {
+		world.execute(func() {
+			world.sessions.remove(player)
+			player.stopWalking()
+		})
+		player.conn.Close()
+	})
+
+	return player
+}
+
+func TestNameRegex(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"Bob", true},
+		{"union42", true},
+		{"abcdefghijklmnop", true},
+		{"", false},
+		{"   ", false},
+		{"Bo", false},
+		{"Bob ", false},
+		{"Bob Smith", false},
+		{"abcdefghijklmnopq", false},
+		{"Bob!", false},
+		{"Zoë", false},
+	}
+
+	for _, test := range tests {
+		if valid := nameRegex.MatchString(test.name); valid != test.valid {
+			t.Errorf("expected %q to be valid: %v", test.name, test.valid)
+		}
+	}
+}
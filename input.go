@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+const MAX_LINE_LENGTH = 512 // Greatest number of bytes a single line of input may contain
+
+var errLineTooLong = errors.New("line too long")
+
+/*
+LineReader buffers everything a client sends and hands it back one line at a time
+Commands split across packets, several commands within one packet and pasted input are all handled
+*/
+type LineReader struct {
+	reader    *bufio.Reader
+	lastWasCR bool // Telnet clients end lines with CR LF (or CR NUL) which must not produce an empty line
+}
+
+func NewLineReader(conn io.Reader) *LineReader {
+	l := new(LineReader)
+	l.reader = bufio.NewReader(conn)
+	return l
+}
+
+/*
+Read the next line without its line ending
+Backspace and delete remove the previous character and other control characters are discarded
+Lines longer than MAX_LINE_LENGTH are consumed entirely and reported with errLineTooLong
+*/
+func (lineReader *LineReader) readLine() (string, error) {
+	line := make([]byte, 0, 64)
+	tooLong := false
+
+	for {
+		b, err := lineReader.reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+
+		if lineReader.lastWasCR {
+			lineReader.lastWasCR = false
+			if b == '\n' || b == 0 {
+				continue
+			}
+		}
+
+		switch {
+		case b == '\r' || b == '\n':
+			lineReader.lastWasCR = b == '\r'
+
+			if tooLong {
+				return "", errLineTooLong
+			}
+			return string(line), nil
+		case b == '\b' || b == 0x7f:
+			// Remove the whole of the last character rather than a single byte of it
+			if len(line) > 0 {
+				_, size := utf8.DecodeLastRune(line)
+				line = line[:len(line)-size]
+			}
+		case b < ' ' && b != '\t':
+			// Ignore any other control characters
+		case len(line) >= MAX_LINE_LENGTH:
+			tooLong = true
+		default:
+			line = append(line, b)
+		}
+	}
+}
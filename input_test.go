@@ -0,0 +1,84 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+type readResult struct {
+	line string
+	err  error
+}
+
+// Read lines until the input runs out
+func readAll(reader io.Reader) []readResult {
+	lineReader := NewLineReader(reader)
+
+	var results []readResult
+	for {
+		line, err := lineReader.readLine()
+		if err == io.EOF {
+			return results
+		}
+		results = append(results, readResult{line, err})
+	}
+}
+
+// Lines which were read successfully
+func lines(texts ...string) []readResult {
+	var results []readResult
+	for _, text := range texts {
+		results = append(results, readResult{text, nil})
+	}
+	return results
+}
+
+func TestReadLine(t *testing.T) {
+	tooLong := strings.Repeat("x", MAX_LINE_LENGTH+1)
+	longest := strings.Repeat("x", MAX_LINE_LENGTH)
+
+	tests := []struct {
+		name    string
+		packets []string // Each packet is returned by a separate read
+		results []readResult
+	}{
+		{"CR LF", []string{"look\r\nmap\r\n"}, lines("look", "map")},
+		{"CR NUL", []string{"look\r\x00map\r\x00"}, lines("look", "map")},
+		{"bare LF", []string{"look\nmap\n"}, lines("look", "map")},
+		{"bare CR", []string{"look\rmap\r"}, lines("look", "map")},
+		{"empty lines", []string{"\r\n\n\r\x00"}, lines("", "", "")},
+		{"several commands in one packet", []string{"move north 3\r\npickup torch\r\nmap\r\n"}, lines("move north 3", "pickup torch", "map")},
+		{"command split across reads", []string{"mo", "ve nor", "th\r\n"}, lines("move north")},
+		{"line ending split across reads", []string{"look\r", "\nmap\r", "\x00"}, lines("look", "map")},
+		{"backspace", []string{"lookk\b\r\n"}, lines("look")},
+		{"delete", []string{"lookk\x7f\r\n"}, lines("look")},
+		{"backspace on an empty line", []string{"\b\x7flook\r\n"}, lines("look")},
+		{"backspace across a two byte rune", []string{"café\be\r\n"}, lines("cafe")},
+		{"backspace across a four byte rune", []string{"hi\U0001F600\x7f!\r\n"}, lines("hi!")},
+		{"backspace across a rune split across reads", []string{"caf\xc3", "\xa9\b\r\n"}, lines("caf")},
+		{"control characters", []string{"lo\x07o\x1bk\tnow\r\n"}, lines("look\tnow")},
+		{"longest line", []string{longest + "\r\n"}, lines(longest)},
+		{"line too long then a valid line", []string{tooLong + "\r\nlook\r\n"}, []readResult{{"", errLineTooLong}, {"look", nil}}},
+		{"unfinished line", []string{"look"}, nil},
+	}
+
+	for _, test := range tests {
+		var readers []io.Reader
+		for _, packet := range test.packets {
+			readers = append(readers, strings.NewReader(packet))
+		}
+
+		if results := readAll(io.MultiReader(readers...)); !reflect.DeepEqual(results, test.results) {
+			t.Errorf("%s: read %v but expected %v", test.name, results, test.results)
+		}
+
+		// Every line must come out the same however the input is broken up
+		oneByte := iotest.OneByteReader(strings.NewReader(strings.Join(test.packets, "")))
+		if results := readAll(oneByte); !reflect.DeepEqual(results, test.results) {
+			t.Errorf("%s: read %v a byte at a time but expected %v", test.name, results, test.results)
+		}
+	}
+}
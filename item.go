@@ -1,14 +1,37 @@
 package main
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const MAX_CARRY_WEIGHT = 50 // Total weight of items a player is able to carry
+
 /*
 Items are objects which are located around the map
 They can be obtained/dropped by a player
 */
 type Item struct {
+	id          string // Identifies the item's definition within the catalog (empty for items which have none)
 	description string
 	coordinates Point
 	isActive    bool
-	itemType ItemType
+	itemType    ItemType
+	quantity    int // Number of items held within a stack (only food stacks)
+}
+
+// Look up the stats of an item (items without a definition have none)
+func (item Item) stats() ItemDefinition {
+	definition := itemCatalog.get(item.id)
+	if definition == nil {
+		return ItemDefinition{ID: item.id, Name: item.description, Type: item.itemType}
+	}
+	return *definition
 }
 
 type ItemType int
@@ -19,4 +42,241 @@ const (
 	Weapon
 	Food
 	Random
-)
\ No newline at end of file
+)
+
+var itemTypeNames = []string{"armour", "weapon", "food", "random"}
+
+func (itemType ItemType) String() string {
+	if itemType < 0 || int(itemType) >= len(itemTypeNames) {
+		return "unknown"
+	}
+	return itemTypeNames[itemType]
+}
+
+func (itemType ItemType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(itemType.String())
+}
+
+// Types are written by name but older saves recorded them as numbers so both are accepted
+func (itemType *ItemType) UnmarshalJSON(data []byte) error {
+	var number int
+	if err := json.Unmarshal(data, &number); err == nil {
+		if number < 0 || number >= len(itemTypeNames) {
+			return fmt.Errorf("unknown item type %d", number)
+		}
+		*itemType = ItemType(number)
+		return nil
+	}
+
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+
+	for i, typeName := range itemTypeNames {
+		if typeName == strings.ToLower(name) {
+			*itemType = ItemType(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown item type %q", name)
+}
+
+// Rarity decides how likely an item is to be found compared to others
+var rarityWeights = map[string]int{
+	"common":    60,
+	"uncommon":  25,
+	"rare":      10,
+	"legendary": 5,
+}
+
+/*
+Item definitions describe every kind of item which can exist and are read from data/items.json and data/food.json
+Items within the world refer back to their definition by id
+*/
+type ItemDefinition struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Type    ItemType `json:"type"`
+	Damage  int      `json:"damage"`  // Extra damage dealt whilst equipped as a weapon
+	Defense int      `json:"defense"` // Damage prevented whilst equipped as armour
+	Heal    int      `json:"heal"`    // Health restored when eaten
+	Value   int      `json:"value"`   // Gold an NPC charges for the item
+	Weight  int      `json:"weight"`
+	Rarity  string   `json:"rarity"`
+
+	Effect       string `json:"effect,omitempty"`       // Poison or regeneration left upon whoever eats the item
+	EffectAmount int    `json:"effectAmount,omitempty"` // Health lost or gained each tick whilst the effect lasts
+	EffectTicks  int    `json:"effectTicks,omitempty"`  // Number of ticks the effect lasts for
+}
+
+// The catalog holds every item definition (kept in file order so generation from a seed is repeatable)
+type ItemCatalog struct {
+	definitions []*ItemDefinition
+	byID        map[string]*ItemDefinition
+}
+
+var itemCatalog = NewItemCatalog()
+
+func NewItemCatalog() *ItemCatalog {
+	catalog := new(ItemCatalog)
+	catalog.byID = make(map[string]*ItemDefinition)
+	return catalog
+}
+
+// Read item definitions from JSON files reporting every problem found within them
+func LoadItemCatalog(paths ...string) (*ItemCatalog, error) {
+	catalog := NewItemCatalog()
+	var problems []string
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var definitions []*ItemDefinition
+		if err := json.Unmarshal(content, &definitions); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		for i, definition := range definitions {
+			problems = append(problems, catalog.add(path, i, definition)...)
+		}
+	}
+
+	if len(catalog.definitions) == 0 {
+		problems = append(problems, "at least one item must be defined")
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+
+	return catalog, nil
+}
+
+// Add a definition to the catalog returning anything wrong with it
+func (catalog *ItemCatalog) add(path string, index int, definition *ItemDefinition) []string {
+	var problems []string
+
+	if definition.ID == "" || definition.Name == "" {
+		return append(problems, fmt.Sprintf("%s: item %d must have an id and a name", path, index))
+	}
+	if _, ok := catalog.byID[definition.ID]; ok {
+		return append(problems, path+": item "+definition.ID+" is defined twice")
+	}
+	if _, ok := rarityWeights[definition.Rarity]; !ok {
+		problems = append(problems, path+": item "+definition.ID+" has unknown rarity "+definition.Rarity)
+	}
+	if definition.Damage < 0 || definition.Defense < 0 || definition.Heal < 0 || definition.Value < 0 || definition.Weight < 0 {
+		problems = append(problems, path+": item "+definition.ID+" has negative stats")
+	}
+	if definition.Effect != "" {
+		if definition.Effect != POISON && definition.Effect != REGENERATION {
+			problems = append(problems, path+": item "+definition.ID+" has unknown effect "+definition.Effect)
+		}
+		if definition.EffectAmount < 1 || definition.EffectTicks < 1 {
+			problems = append(problems, path+": item "+definition.ID+" needs a positive effectAmount and effectTicks")
+		}
+	}
+
+	catalog.definitions = append(catalog.definitions, definition)
+	catalog.byID[definition.ID] = definition
+
+	return problems
+}
+
+// Retrieve a definition by id (nil if there is no such item)
+func (catalog *ItemCatalog) get(id string) *ItemDefinition {
+	return catalog.byID[id]
+}
+
+// Retrieve a definition by its display name (nil if there is no such item)
+func (catalog *ItemCatalog) findByName(name string) *ItemDefinition {
+	for _, definition := range catalog.definitions {
+		if definition.Name == name {
+			return definition
+		}
+	}
+	return nil
+}
+
+// Create an item from its definition placing it at the given coordinates
+func (catalog *ItemCatalog) newItem(definition *ItemDefinition, coordinates Point) Item {
+	return Item{definition.ID, definition.Name, coordinates, true, definition.Type, 1}
+}
+
+// Pick a definition of one of the given types where rarer items are picked less often (nil if none match)
+func (catalog *ItemCatalog) random(rng *rand.Rand, types ...ItemType) *ItemDefinition {
+	var candidates []*ItemDefinition
+	total := 0
+
+	for _, definition := range catalog.definitions {
+		if len(types) == 0 || Contains(types, definition.Type) {
+			candidates = append(candidates, definition)
+			total += rarityWeights[definition.Rarity]
+		}
+	}
+
+	if total == 0 {
+		return nil
+	}
+
+	roll := rng.Intn(total)
+	for _, definition := range candidates {
+		roll -= rarityWeights[definition.Rarity]
+		if roll < 0 {
+			return definition
+		}
+	}
+
+	return nil
+}
+
+// Recreate an item which was saved (records from before the catalog existed are matched by name)
+func itemFromRecord(record ItemRecord, coordinates Point) Item {
+	definition := itemCatalog.get(record.ID)
+	if definition == nil && record.ID == "" {
+		definition = itemCatalog.findByName(record.Description)
+	}
+
+	// Records from before items stacked hold a single item
+	quantity := record.Quantity
+	if quantity < 1 {
+		quantity = 1
+	}
+
+	if definition != nil {
+		item := itemCatalog.newItem(definition, coordinates)
+		item.quantity = quantity
+		return item
+	}
+
+	return Item{record.ID, record.Description, coordinates, true, record.Type, quantity}
+}
+
+func (item Item) toRecord() ItemRecord {
+	return ItemRecord{item.id, item.description, item.itemType, item.quantity}
+}
+
+// Food is the only thing which stacks within an inventory
+func (item Item) isStackable() bool {
+	return item.itemType == Food && item.id != ""
+}
+
+// Name of an item along with how many are within its stack
+func (item Item) label() string {
+	if item.quantity > 1 {
+		return item.description + " x" + strconv.Itoa(item.quantity)
+	}
+	return item.description
+}
+
+// Total weight of a set of items
+func totalWeight(items []Item) int {
+	return Reduce(items, func(item Item, total int) int {
+		return total + item.stats().Weight*item.quantity
+	}, 0)
+}
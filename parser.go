@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var errUnterminatedQuote = errors.New("unterminated quote")
+
+/*
+Split a line of input into words
+Words within double quotes are kept together so `drop "iron sword"` has a single argument
+The command itself is lower cased so commands can be typed in any case
+*/
+func tokenize(line string) ([]string, error) {
+	var tokens []string
+	var token strings.Builder
+	inQuotes := false
+	inToken := false
+
+	for _, r := range line {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			inToken = true
+		case unicode.IsSpace(r) && !inQuotes:
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+		case unicode.IsPrint(r):
+			token.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if inQuotes {
+		return nil, errUnterminatedQuote
+	}
+
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+
+	if len(tokens) > 0 {
+		tokens[0] = strings.ToLower(tokens[0])
+	}
+
+	return tokens, nil
+}
+
+/*
+Find the items whose names match what a player typed ignoring case
+An exact name wins, otherwise names which start with it and then names which contain it
+Items sharing a name are only matched once
+*/
+func matchItems(items []Item, query string) []int {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	tests := []func(name string) bool{
+		func(name string) bool { return name == query },
+		func(name string) bool { return strings.HasPrefix(name, query) },
+		func(name string) bool { return strings.Contains(name, query) },
+	}
+
+	for _, test := range tests {
+		var matches []int
+		seen := make(map[string]bool)
+
+		for i, item := range items {
+			name := strings.ToLower(item.description)
+			if test(name) && !seen[name] {
+				seen[name] = true
+				matches = append(matches, i)
+			}
+		}
+
+		if len(matches) > 0 {
+			return matches
+		}
+	}
+
+	return nil
+}
+
+/*
+Split words into item names by greedily taking the longest run of words which names an item
+So `combine iron bars torch` finds "iron bars" and "torch"
+*/
+func splitItemNames(words []string, items []Item) []string {
+	var names []string
+
+	for i := 0; i < len(words); {
+		end := i + 1
+		for j := len(words); j > i; j-- {
+			if len(matchItems(items, strings.Join(words[i:j], " "))) > 0 {
+				end = j
+				break
+			}
+		}
+
+		names = append(names, strings.Join(words[i:end], " "))
+		i = end
+	}
+
+	return names
+}
+
+/*
+Work out which item a player means returning its index within items
+If nothing matches the missing message is shown and if several do the player is asked to pick one
+after which retry is run again with the full name of their choice (either way -1 is returned)
+*/
+func (player *Player) selectItem(items []Item, query string, missing string, retry func(name string)) int {
+	matches := matchItems(items, query)
+
+	switch len(matches) {
+	case 0:
+		player.displayError(missing)
+		return -1
+	case 1:
+		return matches[0]
+	}
+
+	// Numbered choices take over the player's input until they pick one (whatever they were doing carries on afterwards)
+	previous := player.options
+	options := make(map[string]func(modifiers []string))
+
+	player.writeCompact("Which did you mean?")
+	for i, index := range matches {
+		name := items[index].description
+		options[strconv.Itoa(i+1)] = func(modifiers []string) {
+			player.options = previous
+			retry(name)
+		}
+		player.writeCompact(strconv.Itoa(i+1) + ": " + name)
+	}
+
+	options["cancel"] = func(modifiers []string) {
+		player.options = previous
+		player.write("Cancelled")
+	}
+	player.write("Enter a number or cancel")
+
+	player.options = options
+	return -1
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		line   string
+		tokens []string
+		err    error
+	}{
+		{"", nil, nil},
+		{"   \t ", nil, nil},
+		{"\u200b", nil, nil},
+		{"\u00ad \u200b", nil, nil},
+		{`""`, []string{""}, nil},
+		{"look", []string{"look"}, nil},
+		{"LOOK", []string{"look"}, nil},
+		{"  move   north 3 ", []string{"move", "north", "3"}, nil},
+		{`drop "Iron Sword"`, []string{"drop", "Iron Sword"}, nil},
+		{`combine "iron bars" torch`, []string{"combine", "iron bars", "torch"}, nil},
+		{"lo\u200bok", []string{"look"}, nil},
+		{`drop "iron sword`, nil, errUnterminatedQuote},
+		{`"`, nil, errUnterminatedQuote},
+	}
+
+	for _, test := range tests {
+		tokens, err := tokenize(test.line)
+		if err != test.err || !reflect.DeepEqual(tokens, test.tokens) {
+			t.Errorf("tokenize(%q) = %q, %v but expected %q, %v", test.line, tokens, err, test.tokens, test.err)
+		}
+	}
+}
+
+// Items with only a name which is all matching looks at
+func namedItems(names ...string) []Item {
+	var items []Item
+	for _, name := range names {
+		items = append(items, Item{description: name})
+	}
+	return items
+}
+
+func TestMatchItems(t *testing.T) {
+	items := namedItems("Iron Sword", "Iron Bars", "Torch", "Torch", "Sword")
+
+	tests := []struct {
+		query   string
+		matches []int
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"\u200b", nil},
+		{"sword", []int{4}},
+		{"SWORD", []int{4}},
+		{"iron", []int{0, 1}},
+		{"torch", []int{2}},
+		{"bars", []int{1}},
+		{"shield", nil},
+	}
+
+	for _, test := range tests {
+		if matches := matchItems(items, test.query); !reflect.DeepEqual(matches, test.matches) {
+			t.Errorf("matchItems(%q) = %v but expected %v", test.query, matches, test.matches)
+		}
+	}
+
+	if matches := matchItems(nil, "sword"); matches != nil {
+		t.Errorf("expected nothing to match without items but got %v", matches)
+	}
+}
+
+func TestSplitItemNames(t *testing.T) {
+	items := namedItems("Iron Bars", "Torch", "Iron")
+
+	tests := []struct {
+		words []string
+		names []string
+	}{
+		{nil, nil},
+		{[]string{}, nil},
+		{[]string{""}, []string{""}},
+		{[]string{"torch"}, []string{"torch"}},
+		{[]string{"iron", "bars", "torch"}, []string{"iron bars", "torch"}},
+		{[]string{"iron", "torch"}, []string{"iron", "torch"}},
+		{[]string{"unknown", "torch"}, []string{"unknown", "torch"}},
+		{[]string{"iron bars", "torch"}, []string{"iron bars", "torch"}},
+	}
+
+	for _, test := range tests {
+		if names := splitItemNames(test.words, items); !reflect.DeepEqual(names, test.names) {
+			t.Errorf("splitItemNames(%q) = %q but expected %q", test.words, names, test.names)
+		}
+	}
+}
+
+func TestHandleInputIgnoresEmptyInput(t *testing.T) {
+	world := getWorldInstance()
+	player := newTestPlayer(t, "silent")
+
+	world.execute(func() {
+		player.handleInput(nil)
+		player.handleInput([]string{})
+
+		// Numbered choices are still waiting for an answer afterwards
+		player.options = map[string]func(modifiers []string){"1": func(modifiers []string) {}}
+		player.handleInput(nil)
+
+		if player.options == nil {
+			t.Error("expected empty input to leave the player's options alone")
+		}
+		player.options = nil
+	})
+}
@@ -0,0 +1,173 @@
+/*
+Package pathfinding finds the shortest walkable path across a town's dungeon layout using A*
+Layouts are indexed by [x][y] and only cells holding Floor can be walked on
+*/
+package pathfinding
+
+import (
+	"container/heap"
+	"math"
+)
+
+const Floor = 1 // Value of a walkable cell within a layout
+
+// Cost of moving a single cell straight or diagonally (roughly 10 * the square root of 2)
+const (
+	STRAIGHT_COST = 10
+	DIAGONAL_COST = 14
+)
+
+type Point struct {
+	X int
+	Y int
+}
+
+// Nodes are points waiting to be explored ordered by f = g + h
+type node struct {
+	point Point
+	g     int // Cost of the cheapest path found from the start to this point
+	f     int // g plus the estimated cost from this point to the goal
+	index int // Position within the heap
+}
+
+type openSet []*node
+
+func (set openSet) Len() int { return len(set) }
+
+func (set openSet) Less(i, j int) bool {
+	if set[i].f == set[j].f {
+		return set[i].g > set[j].g // Prefer nodes closer to the goal when tied
+	}
+	return set[i].f < set[j].f
+}
+
+func (set openSet) Swap(i, j int) {
+	set[i], set[j] = set[j], set[i]
+	set[i].index = i
+	set[j].index = j
+}
+
+func (set *openSet) Push(x any) {
+	n := x.(*node)
+	n.index = len(*set)
+	*set = append(*set, n)
+}
+
+func (set *openSet) Pop() any {
+	old := *set
+	n := old[len(old)-1]
+	old[len(old)-1] = nil
+	*set = old[:len(old)-1]
+	return n
+}
+
+// Estimate the cost between two points allowing diagonal moves (never more than the real cost)
+func heuristic(a Point, b Point) int {
+	deltaX := int(math.Abs(float64(a.X - b.X)))
+	deltaY := int(math.Abs(float64(a.Y - b.Y)))
+
+	if deltaX > deltaY {
+		return DIAGONAL_COST*deltaY + STRAIGHT_COST*(deltaX-deltaY)
+	}
+	return DIAGONAL_COST*deltaX + STRAIGHT_COST*(deltaY-deltaX)
+}
+
+/*
+Find the cheapest path from start to goal moving straight or diagonally (diagonals may not cut past a wall)
+The path runs from the step after start up to and including goal
+It is empty if start is goal and nil if goal cannot be reached
+*/
+func FindPath(layout [][]int, start Point, goal Point) []Point {
+	if len(layout) == 0 || !inBounds(layout, start) || !inBounds(layout, goal) || layout[goal.X][goal.Y] != Floor {
+		return nil
+	}
+
+	width, height := len(layout), len(layout[0])
+
+	// Grids indexed by [x][y] replace searching through lists of points
+	visited := make([][]bool, width)
+	costs := make([][]int, width)
+	parents := make([][]Point, width)
+
+	for x := 0; x < width; x++ {
+		visited[x] = make([]bool, height)
+		costs[x] = make([]int, height)
+		parents[x] = make([]Point, height)
+
+		for y := range costs[x] {
+			costs[x][y] = math.MaxInt
+		}
+	}
+
+	open := &openSet{}
+	costs[start.X][start.Y] = 0
+	heap.Push(open, &node{point: start, g: 0, f: heuristic(start, goal)})
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(*node)
+		point := current.point
+
+		// A cheaper route to this point was found after it was queued
+		if visited[point.X][point.Y] {
+			continue
+		}
+		visited[point.X][point.Y] = true
+
+		if point == goal {
+			return buildPath(parents, start, goal)
+		}
+
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				if dx == 0 && dy == 0 {
+					continue
+				}
+
+				next := Point{point.X + dx, point.Y + dy}
+				if !inBounds(layout, next) || visited[next.X][next.Y] || layout[next.X][next.Y] != Floor {
+					continue
+				}
+
+				cost := STRAIGHT_COST
+				if dx != 0 && dy != 0 {
+					// Squeezing diagonally between two walls is not allowed
+					if layout[point.X+dx][point.Y] != Floor || layout[point.X][point.Y+dy] != Floor {
+						continue
+					}
+					cost = DIAGONAL_COST
+				}
+
+				g := current.g + cost
+				if g >= costs[next.X][next.Y] {
+					continue
+				}
+
+				costs[next.X][next.Y] = g
+				parents[next.X][next.Y] = point
+				heap.Push(open, &node{point: next, g: g, f: g + heuristic(next, goal)})
+			}
+		}
+	}
+
+	return nil
+}
+
+func inBounds(layout [][]int, point Point) bool {
+	return point.X >= 0 && point.X < len(layout) && point.Y >= 0 && point.Y < len(layout[0])
+}
+
+// Follow the parents back from the goal to the start
+func buildPath(parents [][]Point, start Point, goal Point) []Point {
+	path := make([]Point, 0)
+
+	for point := goal; point != start; point = parents[point.X][point.Y] {
+		path = append(path, point)
+	}
+
+	// The path was built backwards
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path
+}
@@ -0,0 +1,225 @@
+package pathfinding
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Build a layout from rows drawn as the map is ('#' walkable and '/' wall)
+func parseLayout(rows ...string) [][]int {
+	layout := make([][]int, len(rows[0]))
+	for x := range layout {
+		layout[x] = make([]int, len(rows))
+		for y, row := range rows {
+			if row[x] == '#' {
+				layout[x][y] = Floor
+			}
+		}
+	}
+	return layout
+}
+
+func openLayout(width int, height int) [][]int {
+	layout := make([][]int, width)
+	for x := range layout {
+		layout[x] = make([]int, height)
+		for y := range layout[x] {
+			layout[x][y] = Floor
+		}
+	}
+	return layout
+}
+
+// Walls fill every other column leaving a gap at alternate ends so the only path snakes across the whole layout (width must be odd)
+func serpentineLayout(width int, height int) [][]int {
+	layout := openLayout(width, height)
+	for x := 1; x < width; x += 2 {
+		gap := 0
+		if x%4 == 1 {
+			gap = height - 1
+		}
+		for y := 0; y < height; y++ {
+			if y != gap {
+				layout[x][y] = 0
+			}
+		}
+	}
+	return layout
+}
+
+// Scatter walls over a layout keeping the corners walkable
+func randomLayout(width int, height int, walls float64, seed int64) [][]int {
+	rng := rand.New(rand.NewSource(seed))
+	layout := openLayout(width, height)
+	for x := range layout {
+		for y := range layout[x] {
+			if rng.Float64() < walls {
+				layout[x][y] = 0
+			}
+		}
+	}
+	layout[0][0] = Floor
+	layout[width-1][height-1] = Floor
+	return layout
+}
+
+// Check every step of a path is a single walkable move which never squeezes diagonally past a wall
+func checkPath(t *testing.T, layout [][]int, start Point, goal Point, path []Point) {
+	t.Helper()
+
+	if len(path) == 0 || path[len(path)-1] != goal {
+		t.Fatalf("expected the path to end at %v but got %v", goal, path)
+	}
+
+	previous := start
+	for _, point := range path {
+		dx, dy := point.X-previous.X, point.Y-previous.Y
+		if dx < -1 || dx > 1 || dy < -1 || dy > 1 || (dx == 0 && dy == 0) {
+			t.Fatalf("step from %v to %v is not a single move", previous, point)
+		}
+		if layout[point.X][point.Y] != Floor {
+			t.Fatalf("path walks through the wall at %v", point)
+		}
+		if dx != 0 && dy != 0 && (layout[previous.X+dx][previous.Y] != Floor || layout[previous.X][previous.Y+dy] != Floor) {
+			t.Fatalf("step from %v to %v cuts past a wall", previous, point)
+		}
+		previous = point
+	}
+}
+
+func TestStartIsGoal(t *testing.T) {
+	layout := openLayout(3, 3)
+
+	path := FindPath(layout, Point{1, 1}, Point{1, 1})
+	if path == nil || len(path) != 0 {
+		t.Fatalf("expected an empty path but got %v", path)
+	}
+}
+
+func TestStraightLine(t *testing.T) {
+	layout := openLayout(5, 1)
+
+	path := FindPath(layout, Point{0, 0}, Point{4, 0})
+	checkPath(t, layout, Point{0, 0}, Point{4, 0}, path)
+
+	if len(path) != 4 {
+		t.Fatalf("expected 4 steps but got %v", path)
+	}
+}
+
+func TestDiagonal(t *testing.T) {
+	layout := openLayout(4, 4)
+
+	path := FindPath(layout, Point{0, 0}, Point{3, 3})
+	checkPath(t, layout, Point{0, 0}, Point{3, 3}, path)
+
+	if len(path) != 3 {
+		t.Fatalf("expected 3 diagonal steps but got %v", path)
+	}
+}
+
+func TestAvoidsWalls(t *testing.T) {
+	layout := parseLayout(
+		"#####",
+		"////#",
+		"#####",
+		"#////",
+		"#####",
+	)
+	start, goal := Point{0, 0}, Point{4, 4}
+
+	path := FindPath(layout, start, goal)
+	checkPath(t, layout, start, goal, path)
+
+	// Along the top, down the right, back along the middle, down the left and along the bottom
+	if len(path) != 16 {
+		t.Fatalf("expected the path to snake around the walls in 16 steps but got %d: %v", len(path), path)
+	}
+}
+
+func TestNoCornerCutting(t *testing.T) {
+	layout := parseLayout(
+		"#/",
+		"##",
+	)
+	start, goal := Point{0, 0}, Point{1, 1}
+
+	path := FindPath(layout, start, goal)
+	checkPath(t, layout, start, goal, path)
+
+	if len(path) != 2 || path[0] != (Point{0, 1}) {
+		t.Fatalf("expected the path to go around the wall through [0,1] but got %v", path)
+	}
+}
+
+func TestNoSqueezingBetweenWalls(t *testing.T) {
+	layout := parseLayout(
+		"#/",
+		"/#",
+	)
+
+	if path := FindPath(layout, Point{0, 0}, Point{1, 1}); path != nil {
+		t.Fatalf("expected no path between two walls but got %v", path)
+	}
+}
+
+func TestUnreachableGoal(t *testing.T) {
+	layout := parseLayout(
+		"##/##",
+		"##/##",
+		"##/##",
+	)
+
+	if path := FindPath(layout, Point{0, 0}, Point{4, 2}); path != nil {
+		t.Fatalf("expected no path across the wall but got %v", path)
+	}
+
+	if path := FindPath(layout, Point{0, 0}, Point{2, 1}); path != nil {
+		t.Fatalf("expected no path into a wall but got %v", path)
+	}
+
+	if path := FindPath(layout, Point{0, 0}, Point{9, 9}); path != nil {
+		t.Fatalf("expected no path outside the layout but got %v", path)
+	}
+}
+
+func TestSerpentine(t *testing.T) {
+	layout := serpentineLayout(21, 10)
+	start, goal := Point{0, 0}, Point{20, 9}
+
+	path := FindPath(layout, start, goal)
+	checkPath(t, layout, start, goal, path)
+}
+
+func benchmarkFindPath(b *testing.B, layout [][]int) {
+	start, goal := Point{0, 0}, Point{len(layout) - 1, len(layout[0]) - 1}
+
+	if FindPath(layout, start, goal) == nil {
+		b.Fatal("the far corner cannot be reached")
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FindPath(layout, start, goal)
+	}
+}
+
+func BenchmarkTown30x15(b *testing.B) {
+	benchmarkFindPath(b, randomLayout(30, 15, 0.3, 1))
+}
+
+func BenchmarkOpen1000x1000(b *testing.B) {
+	benchmarkFindPath(b, openLayout(1000, 1000))
+}
+
+func BenchmarkRandom1000x1000(b *testing.B) {
+	benchmarkFindPath(b, randomLayout(1000, 1000, 0.3, 1))
+}
+
+func BenchmarkSerpentine1001x1000(b *testing.B) {
+	benchmarkFindPath(b, serpentineLayout(1001, 1000))
+}
+
+func BenchmarkOpen2000x2000(b *testing.B) {
+	benchmarkFindPath(b, openLayout(2000, 2000))
+}
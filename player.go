@@ -3,85 +3,146 @@ package main
 import (
 	"math"
 	"fmt"
-	"sort"
 	"strconv"
-	"net"
-	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/sid-shakthivel/GUD/pathfinding"
 )
 
 // Player serve as clients to the server which navigate around the world
 type Player struct {
 	coordinates *Point
 	inventory   []Item
-	conn net.Conn
+	conn *TelnetConn
+	input *LineReader
 	name string
+	passwordHash string
 	armour *Item
 	weapon *Item
 	health int
 	gold int
-	actions map[string]func(modifiers []string)
-	currentTown Town
+	options map[string]func(modifiers []string) // Override commands whilst interacting with an event
+	combat *Combat // Fight the player is currently within (nil if not fighting)
+	currentTown *Town // Shared with every other player in the same town
+	channels map[string]bool // Chat channels the player has joined
+	muted map[string]bool // Chat channels (or shouts) the player does not want to hear
+	ignored map[string]bool // Lower case names of players whose messages are hidden
+	effects []*StatusEffect // Poison or regeneration left by food which has been eaten
+	recipes map[string]bool // Ids of recipes the player has discovered by crafting them
+	walk *Walk // Path being followed automatically (nil when standing still)
 }
 
-func NewPlayer(coordinates *Point, conn net.Conn, name string, town Town) *Player {
+func NewPlayer(coordinates *Point, conn *TelnetConn, name string, town *Town) *Player {
 	inventory := make([]Item, 1)
-	inventory[0] = Item{ "blonde", Point {15, 20, 0, 0, nil}, true, Random}
+	inventory[0] = Item{"blonde", "blonde", Point{15, 20}, true, Random, 1}
 
 	p := new(Player)
 	p.coordinates = coordinates
 	p.inventory = inventory
 	p.conn = conn
+	p.input = NewLineReader(p.conn)
 	p.name = name
-	p.health = 100
-	p.gold = 100
+	p.health = config.StartingHealth
+	p.gold = config.StartingGold
 	p.currentTown = town
+	p.channels = make(map[string]bool)
+	p.muted = make(map[string]bool)
+	p.ignored = make(map[string]bool)
+	p.recipes = make(map[string]bool)
 
 	return p
 }
 
+// Run a parsed command (or one of the options whilst interacting with an event)
+func (player *Player) handleInput(parsedInput []string) {
+	if len(parsedInput) == 0 {
+		return
+	}
+
+	// Any command stops a player who is walking somewhere automatically
+	if player.stopWalking() {
+		player.writeCompact("You stop walking")
+	}
+
+	if player.options == nil {
+		commandRegistry.execute(player, parsedInput)
+		return
+	}
+
+	if ContainsKey(player.options, parsedInput[0]) {
+		player.options[parsedInput[0]](parsedInput[1:])
+	} else {
+		player.write("Unknown command")
+	}
+}
+
 /*
 Signature `move {direction} {distance}`
 Player coordinates are manipulated in a direction within an individual town until they hit distance or a wall
 */
 func (player *Player) move(modifiers []string) {
-	// Parse input correctly
-
-	if len(modifiers) < 2 || !isInt(modifiers[1]) || !Contains(GetKeys(directions), modifiers[0]) {
-		player.displayError("")
-		return
-	}
+	direction := modifiers[0]
 
 	distance, err := strconv.Atoi(modifiers[1])
 	if err != nil {
-		player.displayError("")
+		player.displayError("That is too far to walk")
 		return
 	}
 
+	// Nobody needs to walk further than across the town (and walking on the game loop holds up everyone else)
+	longest := config.Width
+	if config.Height > longest {
+		longest = config.Height
+	}
+	distance = clamp(distance, 0, longest)
+
+	// Directions wrap around the edges of the town so a path may lead back to where it began
+	visited := map[Point]bool{*player.coordinates: true}
+
 	for i := 0; i < distance; i++ {
 		oldX := (*player.coordinates).x
 		oldY := (*player.coordinates).y
-		getNewPoint(modifiers[0], player.coordinates)
+		getNewPoint(direction, player.coordinates)
 
 		if !player.isWithinPlayableRegion() {
 			player.coordinates = NewPoint(oldX, oldY)
 			player.write("A wall blocks your path - one must circumvent it")
 			break
 		}
+
+		if visited[*player.coordinates] {
+			player.write("You find yourself back where you have already walked")
+			break
+		}
+		visited[*player.coordinates] = true
 	}
 
 	player.write("- Your position is " + player.coordinates.format())
 
-	// Check if player has encountered an event and trigger one
-	for _, event := range player.currentTown.events {
+	player.triggerEvents()
+}
+
+// Check if player has encountered an event and trigger one
+func (player *Player) triggerEvents() {
+	// Events may remove themselves from the town so loop over a copy
+	townEvents := append([]*Event{}, player.currentTown.events...)
+
+	for _, event := range townEvents {
 		if event.coordinates.x == player.coordinates.x && event.coordinates.y == player.coordinates.y {
 			player.write("You have found a " + event.eventType.String())
 			events[event.eventType](player, event)
+
+			// Stop once an event has taken over the player's input (trading or fighting)
+			if player.options != nil {
+				return
+			}
 		}
 	}
 }
 
 func (player *Player) isWithinPlayableRegion() bool {
-	if (*player.coordinates).y > HEIGHT || (*player.coordinates).x > WIDTH || player.currentTown.dungeonLayout[(*player.coordinates).x][(*player.coordinates).y] == 0 {
+	if (*player.coordinates).y >= config.Height || (*player.coordinates).x >= config.Width || player.currentTown.dungeonLayout[(*player.coordinates).x][(*player.coordinates).y] == 0 {
 		return false
 	}
 	return true
@@ -92,16 +153,10 @@ Signature `scan {distance}`
 Allows player to scan nearby to identify items and eventObjects in all directions
 */
 func (player *Player) scan(modifiers []string) {
-	// Check for parameters
-	if len(modifiers) < 1 || !isInt(modifiers[0]) {
-		player.displayError("")
-		return
-	}
-
 	// Looks for unit block in all directions to check for item and reports back to user
 	distance, err := strconv.Atoi(modifiers[0])
 	if err != nil {
-		player.displayError("")
+		player.displayError("That is too far to scan")
 		return
 	}
 
@@ -110,7 +165,7 @@ func (player *Player) scan(modifiers []string) {
 	// Check coordiantes of all items if they are within distance
 	for _, item := range player.currentTown.items {
 		if int(math.Abs(float64(item.coordinates.x - player.coordinates.x))) <= distance && int(math.Abs(float64(item.coordinates.y - player.coordinates.y))) <= distance {
-			player.writeCompact("Found: " + item.description + " at " + item.coordinates.format())
+			player.writeCompact("Found: " + item.label() + " at " + item.coordinates.format())
 		}
 	}
 	
@@ -122,110 +177,67 @@ func (player *Player) scan(modifiers []string) {
 		}
 	}
 
-	player.write("Scan finished")
-}
+	player.writeCompact("Corpses:")
 
-// Check if a point slice contains a point with the same coordiantes
-func (point Point) ContainsPoint(points []Point) bool {
-	for i := range points {
-		if points[i].x == point.x && points[i].y == point.y {
-			return true
+	for _, corpse := range player.currentTown.corpses {
+		if int(math.Abs(float64(corpse.coordinates.x - player.coordinates.x))) <= distance && int(math.Abs(float64(corpse.coordinates.y - player.coordinates.y))) <= distance {
+			player.writeCompact("Found: the corpse of " + corpse.owner + " at " + corpse.coordinates.format())
 		}
 	}
-	return false
+
+	player.writeCompact("Players:")
+
+	for _, other := range player.otherPlayersInTown() {
+		if int(math.Abs(float64(other.coordinates.x - player.coordinates.x))) <= distance && int(math.Abs(float64(other.coordinates.y - player.coordinates.y))) <= distance {
+			player.writeCompact("Found: " + other.name + " at " + other.coordinates.format())
+		}
+	}
+
+	player.write("Scan finished")
 }
 
 /*
-Signature 'locate {item name}`
-Uses A* path finding algorithm to work out the shortest path between the user and an item
+Signature `locate {target}`
+Uses A* path finding algorithm to work out the shortest path between the user and the nearest target
 Dispays the path to the user
 */
 func (player *Player) locate(modifiers []string) {
-	// Check for parameters
-	if len(modifiers) < 1 { player.displayError("") }
-
-	// Find item position in world
-	itemIndex, item := Find(player.currentTown.items, func (item Item) bool {
-		return item.description == modifiers[0]
+	target := player.resolveTarget(strings.Join(modifiers, " "), func(name string) {
+		player.locate([]string{name})
 	})
 
-	if itemIndex < 0 {
-		player.displayError("Item is not within the world")
+	if target == nil {
 		return
 	}
 
-	itemPosition := item.coordinates
-
-	var openNodes []Point // Nodes that have calculated cost
-	var closedNodes []Point // Nodes that haven't calculated cost
-
-	openNodes = append(openNodes, *player.coordinates) // Add starting node
-
-	for len(openNodes) > 0 {
-		// Sort the open nodes to get the one with the lowest heuristic cost (cost to the actual node)
-		sort.SliceStable(openNodes, func(i, j int) bool {
-			return openNodes[i].hcost < openNodes[j].hcost
-		})
-
-		// Acknowledge the current node has been accounted for and use it
-		currentNode := openNodes[0]
-		closedNodes = append(closedNodes, currentNode)
-		openNodes = RemoveAtIndex(openNodes, 0)
-
-		// Check if target is found
-		if currentNode.x == itemPosition.x && currentNode.y == itemPosition.y {
-			path := make([]Point, 0)
-
-			node := currentNode
-			path = append(path, node)
-
-			player.write("A path has been uncovered - follow it to find the " + player.currentTown.items[itemIndex].description)
+	if len(target.path) == 0 {
+		player.write("You are standing upon " + target.name)
+		return
+	}
 
-			player.write(node.format())
+	player.write("A path has been uncovered - follow it to find " + target.name + " at " + target.coordinates.format())
 
-			for node.parent != nil {
-				node = *node.parent
-				path = append(path, node)
-				player.writeCompact(node.format())
-			}
+	// Long paths across large towns are sent with a single write
+	var lines strings.Builder
+	for _, node := range target.path {
+		lines.WriteString(node.format() + "\n")
+	}
 
-			player.writeCompact("")
+	player.writeCompact(lines.String())
+}
 
-			return
-		} else {
-			// Create a list of adjacent nodes which are walkable from the current node and not closed
-
-			neighbour1 := *NewPoint(min(currentNode.x + 1, WIDTH - 1), currentNode.y)
-			neighbour2 := *NewPoint(max(currentNode.x - 1, 0), currentNode.y,)
-			neighbour3 := *NewPoint(currentNode.x, min(currentNode.y + 1, HEIGHT - 1))
-			neighbour4 := *NewPoint(currentNode.x, max(currentNode.y - 1, 0))
-			neighbour5 := *NewPoint(min(currentNode.x + 1, WIDTH - 1), min(currentNode.y + 1, HEIGHT - 1))
-			neighbour6 := *NewPoint(min(currentNode.x + 1, WIDTH -1), max(currentNode.y - 1, 0))
-			neighbour7 := *NewPoint(max(currentNode.x - 1, 0), min(currentNode.y + 1, HEIGHT - 1))
-			neighbour8 := *NewPoint(max(currentNode.x - 1, 0), max(currentNode.y - 1, 0))
-
-			neighbours := [8]Point { neighbour1, neighbour2, neighbour3, neighbour4, neighbour5, neighbour6, neighbour7, neighbour8 }
-
-			for _, neighbour := range neighbours {
-				// Check if it's walkable (world[neighbour.x][neighbour.y] == 1) and not on the closed list
-				if !neighbour.ContainsPoint(closedNodes) && player.currentTown.dungeonLayout[neighbour.x][neighbour.y] == 1 {
-					cost := calculateHeuristicCost(currentNode, neighbour) + currentNode.gcost
-
-					if cost < neighbour.gcost || !neighbour.ContainsPoint(openNodes) {
-						neighbour.gcost = cost
-						neighbour.hcost = calculateHeuristicCost(neighbour, itemPosition)
-						neighbour.parent = &currentNode
-					}
-
-					if !neighbour.ContainsPoint(openNodes) {
-						openNodes = append(openNodes, neighbour)
-					}
-				}
-			}
-		}
+// Work out the shortest walkable path between two points within a layout (see pathfinding.FindPath)
+func findPath(layout [][]int, start Point, goal Point) []Point {
+	steps := pathfinding.FindPath(layout, pathfinding.Point{X: start.x, Y: start.y}, pathfinding.Point{X: goal.x, Y: goal.y})
+	if steps == nil {
+		return nil
 	}
 
-	player.displayError("Cannot locate item")
+	path := make([]Point, 0, len(steps))
+	for _, step := range steps {
+		path = append(path, Point{step.X, step.Y})
+	}
+	return path
 }
 
 /*
@@ -233,35 +245,47 @@ Signature `pickup {item}`
 Add item to inventory
 */
 func (player *Player) pickup(modifiers []string) {
-	// Check for parameters
-	if len(modifiers) < 1 { player.displayError("") }
+	query := strings.Join(modifiers, " ")
 
-	// Search items array for item requested to get item
-	itemIndex, item := Find(player.currentTown.items, func (item Item) bool {
-		return item.description == modifiers[0]
-	})
+	// Only the items where the player stands can be picked up
+	var itemsHere []Item
+	var indices []int
 
-	if itemIndex < 0 {
-		player.displayError("Item requested is not present within map")
+	for i, item := range player.currentTown.items {
+		if item.coordinates.x == player.coordinates.x && item.coordinates.y == player.coordinates.y {
+			itemsHere = append(itemsHere, item)
+			indices = append(indices, i)
+		}
+	}
+
+	if len(matchItems(itemsHere, query)) == 0 {
+		if len(matchItems(player.currentTown.items, query)) > 0 {
+			player.displayError("You are not at the location of the item")
+		} else {
+			player.displayError("Item requested is not present within map")
+		}
 		return
 	}
 
-	if item.coordinates.x != player.coordinates.x || item.coordinates.y != player.coordinates.y {
-		player.displayError("You are not at the location of the item")
+	hereIndex := player.selectItem(itemsHere, query, "", func(name string) {
+		player.pickup([]string{name})
+	})
+
+	if hereIndex < 0 {
 		return
 	}
 
-	// Check the type of the item to determine what to do
+	itemIndex := indices[hereIndex]
+	item := player.currentTown.items[itemIndex]
 
-	switch item.itemType {
-	case Random, Weapon, Armour:
-		player.inventory = append(player.inventory, item)
-		player.write("Picked up " + item.description)
-		player.currentTown.items = RemoveAtIndex(player.currentTown.items, itemIndex)
-	default:
-		player.displayError("Cannot pickup an events object - investigate it pronto")
+	if totalWeight(player.inventory)+item.stats().Weight*item.quantity > MAX_CARRY_WEIGHT {
+		player.displayError("You are carrying too much to pick up " + item.description)
 		return
 	}
+
+	player.addToInventory(item)
+	player.write("Picked up " + item.label())
+	player.currentTown.items = RemoveAtIndex(player.currentTown.items, itemIndex)
 }
 
 /*
@@ -269,22 +293,17 @@ Signature `drop {item}`
 Remove item from inventory and place at a coordinate
 */
 func (player *Player) drop(modifiers []string) {
-	// Check for parameters
-	if len(modifiers) < 1 {
-		player.displayError("")
-		return
-	}
-
 	// Search inventory for the item, remove it from inventory and append to items global
-	itemIndex, item := Find(player.inventory, func (item Item) bool {
-		return item.description == modifiers[0]
+	itemIndex := player.selectItem(player.inventory, strings.Join(modifiers, " "), "Item requested is not present within your inventory", func(name string) {
+		player.drop([]string{name})
 	})
 
 	if itemIndex < 0 {
-		player.displayError("Item requested is not present within your inventory")
 		return
 	}
 
+	item := player.inventory[itemIndex]
+
 	// Remove from weapon/armour
 	if player.weapon != nil && player.weapon.description == item.description {
 		player.weapon = nil
@@ -294,55 +313,40 @@ func (player *Player) drop(modifiers []string) {
 		player.armour = nil
 	}
 
+	// Place the item where the player stands so others in the town can find it (one at a time from a stack)
+	item = player.takeFromInventory(itemIndex)
+	item.coordinates = *player.coordinates
 	player.currentTown.items = append(player.currentTown.items, item)
-	player.inventory = RemoveAtIndex(player.inventory, itemIndex)
 
 	player.write("Dropped " + item.description)
 }
 
-/*
-Signature `combine {item1} {item2}
-Combines item to solve puzzles (when included)
-*/
-func (player *Player) combine(modifiers []string) {
-	// Check for parameters
-	if len(modifiers) < 2 {
-		player.displayError("")
-		return
-	}
-
-	if modifiers[0] == modifiers[1] {
-		player.displayError("You have chosen to combine the same item")
-		return
+// Add an item to the inventory stacking it with any of the same kind
+func (player *Player) addToInventory(item Item) {
+	if item.isStackable() {
+		for i := range player.inventory {
+			if player.inventory[i].id == item.id {
+				player.inventory[i].quantity += item.quantity
+				return
+			}
+		}
 	}
 
-	firstItemPosition, firstItem := Find(player.inventory, func (item Item) bool {
-		return item.description == modifiers[0]
-	})
-
-	secondItemPosition, secondItem := Find(player.inventory, func (item Item) bool {
-		return item.description == modifiers[1]
-	})
+	player.inventory = append(player.inventory, item)
+}
 
-	if firstItemPosition < 0 || secondItemPosition < 0 {
-		player.displayError("You do not possess both items")
-		return
-	}
+// Remove a single item from the inventory (taking one from the top of a stack)
+func (player *Player) takeFromInventory(index int) Item {
+	item := player.inventory[index]
 
-	if firstItem.itemType != Random || secondItem.itemType != Random {
-		player.displayError("You can only combine random items")
-		return
+	if item.quantity > 1 {
+		player.inventory[index].quantity--
+		item.quantity = 1
+		return item
 	}
 
-	combinedItem := Item {firstItem.description + secondItem.description, firstItem.coordinates, true, Random }
-
-	player.inventory = append(player.inventory, combinedItem)
-
-	// Remove both items from players inventory
-	player.inventory = RemoveAtIndex(player.inventory, firstItemPosition)
-	player.inventory = RemoveAtIndex(player.inventory, secondItemPosition)
-
-	player.write("Combined " + modifiers[0] + " and " + modifiers[1] + " to create a " + combinedItem.description)
+	player.inventory = RemoveAtIndex(player.inventory, index)
+	return item
 }
 
 /*
@@ -350,28 +354,26 @@ Signature `equip {item1}`
 Equips the weapon/armour to a player
 */
 func (player *Player) equip(modifiers[] string) {
-	if len(modifiers) < 1 {
-		player.displayError("")
-		return
-	}
-
 	// Get item information
-	itemIndex, item := Find(player.inventory, func (item Item) bool {
-		return item.description == modifiers[0]
+	itemIndex := player.selectItem(player.inventory, strings.Join(modifiers, " "), "Item not found within inventory", func(name string) {
+		player.equip([]string{name})
 	})
 
 	if itemIndex < 0 {
-		player.displayError("Item not found within inventory")
 		return
 	}
 
-	switch item.itemType {
+	item := player.inventory[itemIndex]
+
+	stats := item.stats()
+
+	switch stats.Type {
 	case Armour:
 		player.armour = &item
-		player.write("Equiped " + item.description + " as armour")
+		player.write("Equiped " + item.description + " as armour (defense " + strconv.Itoa(stats.Defense) + ")")
 	case Weapon:
 		player.weapon = &item
-		player.write("Equiped " + item.description + " as weapon")
+		player.write("Equiped " + item.description + " as weapon (damage " + strconv.Itoa(stats.Damage) + ")")
 	default:
 		player.displayError("Item cannot be equiped")
 	}
@@ -382,22 +384,18 @@ Signature `unequip {item1}`
 Equips the weapon/armour to a player
 */
 func (player *Player) unequip(modifiers[] string) {
-	if len(modifiers) < 1 {
-		player.displayError("")
-		return
-	}
-
 	// Get item information
-	itemIndex, item := Find(player.inventory, func (item Item) bool {
-		return item.description == modifiers[0]
+	itemIndex := player.selectItem(player.inventory, strings.Join(modifiers, " "), "Item not found within inventory", func(name string) {
+		player.unequip([]string{name})
 	})
 
 	if itemIndex < 0 {
-		player.displayError("Item not found within inventory")
 		return
 	}
 
-	switch item.itemType {
+	item := player.inventory[itemIndex]
+
+	switch item.stats().Type {
 	case Armour:
 		if player.armour == nil {
 			player.displayError("Armour is not equipped")
@@ -435,14 +433,105 @@ func (player *Player) quit(modifiers []string) {
 	player.conn.Close()
 }
 
-func (player *Player) help(modifiers []string) {
-	player.write("Here lies the possible combinations once can enter")
+/*
+Signature `who`
+Lists every player who is logged in along with where they are and how long they have been idle
+*/
+func (player *Player) who(modifiers []string) {
+	sessions := getWorldInstance().sessions.all()
+
+	player.writeCompact(fmt.Sprintf("%-16s %-16s %-10s %s", "Name", "Town", "Idle", "Online"))
+
+	for _, session := range sessions {
+		idle := formatDuration(time.Since(session.lastInput))
+		online := formatDuration(time.Since(session.connectedAt))
+		player.writeCompact(fmt.Sprintf("%-16s %-16s %-10s %s", session.player.name, session.player.currentTown.name, idle, online))
+	}
+
+	player.write(strconv.Itoa(len(sessions)) + " union members are among us")
+}
+
+/*
+Signature `inventory`
+Lists everything a player is carrying along with what it does
+*/
+func (player *Player) inventoryList(modifiers []string) {
+	if len(player.inventory) == 0 {
+		player.write("You are carrying nothing")
+		return
+	}
+
+	for _, item := range player.inventory {
+		stats := item.stats()
+		details := item.itemType.String()
+
+		switch item.itemType {
+		case Weapon:
+			details += ", damage " + strconv.Itoa(stats.Damage)
+		case Armour:
+			details += ", defense " + strconv.Itoa(stats.Defense)
+		case Food:
+			details += ", heals " + strconv.Itoa(stats.Heal)
+			if stats.Effect != "" {
+				details += ", " + stats.Effect
+			}
+		}
+
+		if (player.weapon != nil && player.weapon.description == item.description) || (player.armour != nil && player.armour.description == item.description) {
+			details += ", equipped"
+		}
+
+		player.writeCompact(item.label() + " (" + details + ")")
+	}
 
-	for _, action := range GetKeys(player.actions) {
-		player.writeCompact(action)
+	player.write("Carrying: " + strconv.Itoa(totalWeight(player.inventory)) + "/" + strconv.Itoa(MAX_CARRY_WEIGHT))
+}
+
+/*
+Signature `look`
+Describes the town along with everything where the player stands
+*/
+func (player *Player) look(modifiers []string) {
+	town := player.currentTown
+	here := func(point Point) bool {
+		return point.x == player.coordinates.x && point.y == player.coordinates.y
 	}
+
+	player.writeCompact(town.name + " - " + town.description)
+	player.writeCompact("You stand at " + player.coordinates.format())
+
+	for _, item := range town.items {
+		if here(item.coordinates) {
+			player.writeCompact("Lying here: " + item.label())
+		}
+	}
+
+	for _, event := range town.events {
+		if here(event.coordinates) {
+			player.writeCompact("Here: " + event.eventType.String())
+		}
+	}
+
+	for _, corpse := range town.corpses {
+		if here(corpse.coordinates) {
+			player.writeCompact("Here lies the corpse of " + corpse.owner)
+		}
+	}
+
+	for _, other := range player.otherPlayersInTown() {
+		if here(*other.coordinates) {
+			player.writeCompact(other.name + " stands beside you")
+		}
+	}
+
+	if direction := town.gateAt(*player.coordinates); direction >= 0 {
+		player.writeCompact("You stand upon the gate to " + town.adjacentTowns[direction].name)
+	}
+
 	player.writeCompact("")
+	player.listRoutes()
 }
+
 func (player *Player) viewStats(modifiers []string) {
 	player.conn.Write([]byte("\nName : " + player.name + "\n"))
 	player.conn.Write([]byte("Position: " + player.coordinates.format() + "\n"))
@@ -452,19 +541,20 @@ func (player *Player) viewStats(modifiers []string) {
 	if player.armour == nil {
 		player.writeCompact("Armour: Not Equiped")
 	} else {
-		player.writeCompact("Armour: " + player.armour.description)
+		player.writeCompact("Armour: " + player.armour.description + " (defense " + strconv.Itoa(player.armour.stats().Defense) + ")")
 	}
 
 	if player.weapon == nil {
 		player.writeCompact("Weapon: Not Equiped")
 	} else {
-		player.writeCompact("Weapon: " + player.weapon.description)
+		player.writeCompact("Weapon: " + player.weapon.description + " (damage " + strconv.Itoa(player.weapon.stats().Damage) + ")")
 	}
 
+	player.writeCompact("Carrying: " + strconv.Itoa(totalWeight(player.inventory)) + "/" + strconv.Itoa(MAX_CARRY_WEIGHT))
 	player.conn.Write([]byte("Inventory contents: "))
 
 	for _, item := range player.inventory {
-		player.conn.Write([]byte(item.description + " "))
+		player.conn.Write([]byte(item.label() + " "))
 	}
 	player.write("")
 }
@@ -473,20 +563,53 @@ func (player *Player) viewStats(modifiers []string) {
 func (player *Player) printMap(modifiers []string) {
 	player.writeCompact("")
 
+	// Only show the part of the map surrounding the player if the client's window is too small for all of it
+	startX, endX := 0, config.Width
+	startY, endY := 0, config.Height
+
+	windowWidth, windowHeight := player.conn.windowSize()
+
+	if windowWidth > 0 && windowWidth < config.Width {
+		startX = clamp(player.coordinates.x-windowWidth/2, 0, config.Width-windowWidth)
+		endX = startX + windowWidth
+	}
+
+	// Leave space for the prompt beneath the map
+	if windowHeight > 2 && windowHeight-2 < config.Height {
+		startY = clamp(player.coordinates.y-(windowHeight-2)/2, 0, config.Height-(windowHeight-2))
+		endY = startY + windowHeight - 2
+	}
+
+	// Other players within the town are drawn as @
+	others := make(map[Point]bool)
+	for _, other := range player.otherPlayersInTown() {
+		others[*NewPoint(other.coordinates.x, other.coordinates.y)] = true
+	}
+
+	// Drawn into a single buffer so even the largest map is sent with one write
+	var drawing strings.Builder
+	drawing.Grow((endX - startX + 1) * (endY - startY))
+
 	worldMap := player.currentTown.dungeonLayout
-	for i := 0; i < HEIGHT; i++ {
-		for j := 0; j < WIDTH; j++ {
+	for i := startY; i < endY; i++ {
+		for j := startX; j < endX; j++ {
 			if j == player.coordinates.x && i == player.coordinates.y {
-				player.conn.Write([]byte("X"))
+				drawing.WriteByte('X')
+			} else if others[*NewPoint(j, i)] {
+				drawing.WriteByte('@')
+			} else if player.currentTown.gateAt(*NewPoint(j, i)) >= 0 {
+				drawing.WriteByte('G')
 			} else if worldMap[j][i] == 1 {
-				player.conn.Write([]byte("#"))
+				drawing.WriteByte('#')
 			} else {
-				player.conn.Write([]byte("/"))
+				drawing.WriteByte('/')
 			}
 		}
-		player.writeCompact("")
+		drawing.WriteByte('\n')
 	}
-	player.writeCompact("")
+
+	player.conn.Write([]byte(drawing.String()))
+	player.write("X marks yourself, @ other union members and G the gates to other towns")
 }
 
 /*
@@ -499,23 +622,29 @@ func (player *Player) buyItem(modifiers []string, items *[]Item) {
 		return
 	}
 
-	itemIndex, item := Find(*items, func (item Item) bool {
-		return item.description == modifiers[0]
+	itemIndex := player.selectItem(*items, strings.Join(modifiers, " "), "Sorry I do not sell that item", func(name string) {
+		player.buyItem([]string{name}, items)
 	})
 
 	if itemIndex < 0 {
-		player.displayError("Sorry I do not sell that item")
 		return
 	}
 
-	price := rand.Intn(10) // Generate random price
+	item := (*items)[itemIndex]
+
+	price := item.stats().Value
 
 	if player.gold - price < 0 {
 		player.displayError("You possess insufficient funds to purchase from the vendor")
 		return
 	}
 
-	player.inventory = append(player.inventory, item)
+	if totalWeight(player.inventory)+item.stats().Weight > MAX_CARRY_WEIGHT {
+		player.displayError("You are carrying too much to take " + item.description)
+		return
+	}
+
+	player.addToInventory(item)
 	player.write("Purchased " + item.description + " for " + strconv.Itoa(price) + " gold")
 	player.gold -= price
 	*items = RemoveAtIndex(*items, itemIndex)
@@ -531,33 +660,38 @@ func (player *Player) sellItem(modifiers []string, items *[]Item) {
 		return
 	}
 
-	itemIndex, item := Find(player.inventory, func (item Item) bool {
-		return item.description == modifiers[0]
+	itemIndex := player.selectItem(player.inventory, strings.Join(modifiers, " "), "You do not possess such an item", func(name string) {
+		player.sellItem([]string{name}, items)
 	})
 
 	if itemIndex < 0 {
-		player.displayError("You do not possess such an item")
 		return
 	}
 
-	price := rand.Intn(10) // Generate random price
+	item := player.inventory[itemIndex]
+
+	// Vendors only pay half of what they would charge
+	price := item.stats().Value / 2
+
+	// Selling equipped items leaves the player without them
+	if player.weapon != nil && player.weapon.description == item.description {
+		player.weapon = nil
+	}
+
+	if player.armour != nil && player.armour.description == item.description {
+		player.armour = nil
+	}
 
-	*items = append(*items, item)
+	*items = append(*items, player.takeFromInventory(itemIndex))
 	player.write("Sold " + item.description + " for " + strconv.Itoa(price) + " gold")
 	player.gold += price
-	player.inventory = RemoveAtIndex(player.inventory, itemIndex)
 }
 
 /*
 Signature: `jump {direction}`
-Transports a player to another town within the world
+Transports a player standing upon a gate to another town within the world arriving at the gate which leads back
 */
 func (player *Player) jump(modifiers[]string) {
-	if len(modifiers) > 1 {
-		player.displayError("")
-		return
-	}
-
 	isRoom, message, townIndex := player.currentTown.checkAdjacentTown(modifiers[0])
 
 	if !isRoom {
@@ -565,44 +699,96 @@ func (player *Player) jump(modifiers[]string) {
 		return
 	}
 
+	previousTown := player.currentTown
+	nextTown := player.currentTown.adjacentTowns[townIndex]
+
+	gate := player.currentTown.gates[townIndex]
+	if gate == nil {
+		player.displayError("There is no gate leading to " + nextTown.name)
+		return
+	}
+
+	if *gate != *player.coordinates {
+		player.displayError("You must stand upon the gate to " + nextTown.name + " at " + gate.format() + " to go " + modifiers[0])
+		return
+	}
+
+	// Let everybody within both towns know who is coming and going
+	for _, other := range player.otherPlayersInTown() {
+		other.write(player.name + " has left for " + nextTown.name)
+	}
+
 	// Move player and provide a random town description
-	player.currentTown = player.currentTown.adjacentTowns[townIndex]
+	player.currentTown = nextTown
+
+	// Towns which do not lead back (only found within worlds saved before gates existed) have no gate to arrive at
+	if arrival := nextTown.gates[oppositeDirections[townIndex]]; arrival != nil {
+		player.coordinates = NewPoint(arrival.x, arrival.y)
+	} else {
+		player.coordinates = findFreeLocationInDungeon(getWorldInstance().rng, nextTown.dungeonLayout)
+	}
+
+	for _, other := range player.otherPlayersInTown() {
+		other.write(player.name + " has arrived from " + previousTown.name)
+	}
 
 	player.writeCompact("")
 	player.write(player.currentTown.description)
 	player.listRoutes()
+}
 
-	fmt.Println("test")
+// Retrieve every other player who is logged in and within the same town
+func (player *Player) otherPlayersInTown() []*Player {
+	others := make([]*Player, 0)
+	for _, other := range getWorldInstance().sessions.players() {
+		if other != player && other.currentTown == player.currentTown {
+			others = append(others, other)
+		}
+	}
+	return others
 }
 
 /*
 Signature: `eat {item}
-Allows user to increase health (by random amount) by eating food within inventory
+Allows user to increase health by eating food within inventory
 */
 func (player *Player) eat(modifiers []string) {
-	if len(modifiers) < 1 {
-		player.displayError("")
-		return
-	}
-
-	itemIndex, item := Find(player.inventory, func (item Item) bool {
-		return item.description == modifiers[0]
+	player.eatItem(strings.Join(modifiers, " "), func(name string) {
+		player.eat([]string{name})
 	})
+}
 
-	if itemIndex < -1 {
-		player.displayError("Item is not within index")
-		return
+// Eat a food item from the inventory returning whether it was eaten (retry is used if the player must choose between foods)
+func (player *Player) eatItem(query string, retry func(name string)) bool {
+	itemIndex := player.selectItem(player.inventory, query, "Item is not within index", retry)
+	if itemIndex < 0 {
+		return false
 	}
 
+	item := player.inventory[itemIndex]
+
 	if item.itemType != Food {
 		player.displayError("You can't eat that")
-		return
+		return false
 	}
 
-	player.inventory = RemoveAtIndex(player.inventory, itemIndex)
-	player.health = min(rand.Intn(10) + player.health, 100)
+	// Food from before the catalog existed restores a random amount
+	stats := item.stats()
+	if itemCatalog.get(item.id) == nil {
+		stats.Heal = getWorldInstance().rng.Intn(10)
+	}
+
+	player.takeFromInventory(itemIndex)
+	player.health = clamp(stats.Heal + player.health, 0, player.maxHealth())
 
-	player.write("Your health is now: " + strconv.Itoa(player.health))
+	player.write("You eat " + item.description + " - your health is now: " + strconv.Itoa(player.health))
+	player.addEffect(stats)
+	return true
+}
+
+// Health can never be restored beyond what a new player starts with
+func (player *Player) maxHealth() int {
+	return config.StartingHealth
 }
 
 func (player *Player) listRoutes() {
@@ -612,6 +798,18 @@ func (player *Player) listRoutes() {
 	player.writeCompact("")
 }
 
+// Wait for the next line of input from the player, warning them about lines which are too long
+func (player *Player) readLine() (string, error) {
+	for {
+		line, err := player.input.readLine()
+		if err == errLineTooLong {
+			player.displayError("Your input is too long and has been ignored")
+			continue
+		}
+		return line, err
+	}
+}
+
 func (player *Player) write(text string) {
 	player.conn.Write([]byte(text + "\n\n"))
 }
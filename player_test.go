@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// A town without any walls so every direction wraps around its edges
+func newOpenTown(name string) *Town {
+	town := new(Town)
+	town.name = name
+	town.adjacentTowns = make([]*Town, 4)
+	town.dungeonLayout = newLayout(config.Width, config.Height)
+
+	for x := range town.dungeonLayout {
+		for y := range town.dungeonLayout[x] {
+			town.dungeonLayout[x][y] = 1
+		}
+	}
+
+	return town
+}
+
+func TestMoveDistanceIsBounded(t *testing.T) {
+	world := getWorldInstance()
+	player := newTestPlayer(t, "wanderer")
+
+	for _, direction := range []string{"north", "south", "east", "west", "northeast", "northwest", "southeast", "southwest"} {
+		started := time.Now()
+
+		world.execute(func() {
+			player.currentTown = newOpenTown("Openfield")
+			player.handleInput([]string{"move", direction, "9000000000000000000"})
+
+			if player.coordinates.x < 0 || player.coordinates.x >= config.Width || player.coordinates.y < 0 || player.coordinates.y >= config.Height {
+				t.Errorf("moving %s left the player outside the town at %s", direction, player.coordinates.format())
+			}
+		})
+
+		if elapsed := time.Since(started); elapsed > time.Second {
+			t.Errorf("moving %s a huge distance took %s", direction, elapsed)
+		}
+	}
+}
+
+func TestMoveDiagonally(t *testing.T) {
+	world := getWorldInstance()
+	player := newTestPlayer(t, "diagonal")
+
+	world.execute(func() {
+		player.currentTown = newOpenTown("Openfield")
+		player.coordinates = NewPoint(5, 5)
+		player.handleInput([]string{"move", "northeast", "2"})
+
+		if player.coordinates.x != 7 || player.coordinates.y != 7 {
+			t.Errorf("expected moving northeast twice from [5,5] to reach [7,7] but reached %s", player.coordinates.format())
+		}
+	})
+}
+
+func TestEatFoodMissingFromCatalog(t *testing.T) {
+	world := getWorldInstance()
+	player := newTestPlayer(t, "glutton")
+
+	world.execute(func() {
+		player.health = 50
+		player.inventory = []Item{{"", "ancient loaf", Point{}, true, Food, 1}}
+		player.handleInput([]string{"eat", "ancient", "loaf"})
+
+		if len(player.inventory) != 0 {
+			t.Error("expected the food to be eaten")
+		}
+
+		if player.health < 50 || player.health >= 60 {
+			t.Errorf("expected food missing from the catalog to restore less than 10 health but health is %d", player.health)
+		}
+	})
+}
+
+func TestEquipAndUnequipUseCatalogType(t *testing.T) {
+	world := getWorldInstance()
+	player := newTestPlayer(t, "armourer")
+
+	world.execute(func() {
+		// The catalog decides what an item is even if the item itself says otherwise
+		sword := itemCatalog.newItem(itemCatalog.get("iron_sword"), Point{})
+		sword.itemType = Random
+		player.inventory = []Item{sword}
+
+		player.handleInput([]string{"equip", "iron", "sword"})
+		if player.weapon == nil {
+			t.Error("expected the iron sword to be equipped as a weapon")
+			return
+		}
+
+		player.handleInput([]string{"unequip", "iron", "sword"})
+		if player.weapon != nil {
+			t.Error("expected the iron sword to be unequipped")
+		}
+	})
+}
+
+func TestLargeMapIsSent(t *testing.T) {
+	world := getWorldInstance()
+	server, client := net.Pipe()
+	defer client.Close()
+
+	player := NewPlayer(NewPoint(0, 0), NewTelnetConn(server), "cartographer", world.towns[0])
+
+	received := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(client)
+		received <- data
+	}()
+
+	// The largest towns produce a map far bigger than anything else written at once
+	world.execute(func() {
+		width, height := config.Width, config.Height
+		config.Width, config.Height = 400, 400
+		defer func() {
+			config.Width, config.Height = width, height
+		}()
+
+		player.currentTown = newOpenTown("Vastmoor")
+		player.printMap(nil)
+	})
+	player.conn.Close()
+
+	select {
+	case data := <-received:
+		if tiles := bytes.Count(data, []byte("#")); tiles != 400*400-1 {
+			t.Fatalf("expected every tile of the map to be sent but received %d", tiles)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the map was never sent")
+	}
+}
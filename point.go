@@ -6,11 +6,8 @@ import (
 )
 
 type Point struct {
-	x      int
-	y      int
-	gcost  int // Cost of start point to end goal
-	hcost  int // Heuristic cost estimated cost from node to goal
-	parent *Point
+	x int
+	y int
 }
 
 func (point Point) format() string {
@@ -26,13 +23,13 @@ func NewPoint(x int, y int) *Point {
 
 var manhattanDirections = map[string]func(point *Point){
 	"north": func(point *Point) {
-		point.y = min((*point).y+1, HEIGHT)
+		point.y = min((*point).y+1, config.Height)
 	},
 	"south": func(point *Point) {
 		point.y = max((*point).y-1, 0)
 	},
 	"east": func(point *Point) {
-		point.x = min((*point).x+1, WIDTH)
+		point.x = min((*point).x+1, config.Width)
 	},
 	"west": func(point *Point) {
 		point.x = max((*point).x-1, 0)
@@ -41,28 +38,28 @@ var manhattanDirections = map[string]func(point *Point){
 
 var directions = map[string]func(point *Point){
 	"north": func(point *Point) {
-		point.y = min((*point).y+1, HEIGHT)
+		point.y = min((*point).y+1, config.Height)
 	},
 	"south": func(point *Point) {
 		point.y = max((*point).y-1, 0)
 	},
 	"east": func(point *Point) {
-		point.x = min((*point).x+1, WIDTH)
+		point.x = min((*point).x+1, config.Width)
 	},
 	"west": func(point *Point) {
 		point.x = max((*point).x-1, 0)
 	},
 	"northeast": func(point *Point) {
-		point.y = min((*point).y+1, HEIGHT)
-		point.x = min((*point).x+1, WIDTH)
+		point.y = min((*point).y+1, config.Height)
+		point.x = min((*point).x+1, config.Width)
 	},
 	"northwest": func(point *Point) {
-		point.y = min((*point).y+1, HEIGHT)
+		point.y = min((*point).y+1, config.Height)
 		point.x = max((*point).x-1, 0)
 	},
 	"southeast": func(point *Point) {
 		point.y = max((*point).y-1, 0)
-		point.x = min((*point).x+1, WIDTH)
+		point.x = min((*point).x+1, config.Width)
 	},
 	"southwest": func(point *Point) {
 		point.y = max((*point).y-1, 0)
@@ -70,40 +67,42 @@ var directions = map[string]func(point *Point){
 	},
 }
 
-func pickPerpendicularRandomDirection(lastDirection string) string {
-	directionsList := GetKeys(manhattanDirections)
-	newDirection := directionsList[rand.Intn(len(directionsList)-1)]
+// Names of manhattanDirections in a fixed order (iterating over the map itself would make generation unrepeatable)
+var manhattanDirectionNames = []string{"north", "south", "east", "west"}
+
+func pickPerpendicularRandomDirection(rng *rand.Rand, lastDirection string) string {
+	newDirection := manhattanDirectionNames[rng.Intn(len(manhattanDirectionNames))]
 
 	if lastDirection == "north" && newDirection == "south" || lastDirection == "north" && newDirection == "north" {
-		return pickPerpendicularRandomDirection(lastDirection)
+		return pickPerpendicularRandomDirection(rng, lastDirection)
 	}
 
 	if lastDirection == "south" && newDirection == "north" || lastDirection == "south" && newDirection == "south" {
-		return pickPerpendicularRandomDirection(lastDirection)
+		return pickPerpendicularRandomDirection(rng, lastDirection)
 	}
 
 	if lastDirection == "east" && newDirection == "east" || lastDirection == "east" && newDirection == "west" {
-		return pickPerpendicularRandomDirection(lastDirection)
+		return pickPerpendicularRandomDirection(rng, lastDirection)
 	}
 
 	if lastDirection == "west" && newDirection == "east" || lastDirection == "west" && newDirection == "west" {
-		return pickPerpendicularRandomDirection(lastDirection)
+		return pickPerpendicularRandomDirection(rng, lastDirection)
 	}
 
 	return newDirection
 }
 
-func findFreeLocationInDungeon(worldMap [WIDTH][HEIGHT]int) *Point {
+func findFreeLocationInDungeon(rng *rand.Rand, worldMap [][]int) *Point {
 	// Get random coordinate and ensure dungeon space exists there
-	randX := rand.Intn(WIDTH)
-	randY := rand.Intn(HEIGHT)
+	randX := rng.Intn(len(worldMap))
+	randY := rng.Intn(len(worldMap[0]))
 
 	pos := worldMap[randX][randY]
 
 	if pos == 1 {
 		return NewPoint(randX, randY)
 	} else {
-		return findFreeLocationInDungeon(worldMap)
+		return findFreeLocationInDungeon(rng, worldMap)
 	}
 }
 
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+/*
+Recipes turn a set of ingredients into a new item and are read from data/recipes.json
+Ingredients and outputs are ids of items within the catalog
+*/
+type Recipe struct {
+	ID          string   `json:"id"`
+	Ingredients []string `json:"ingredients"`
+	Output      string   `json:"output"`
+}
+
+// Ingredients in a fixed order so recipes can be compared regardless of the order items were given in
+func (recipe *Recipe) key() string {
+	ingredients := append([]string(nil), recipe.Ingredients...)
+	sort.Strings(ingredients)
+	return strings.Join(ingredients, "+")
+}
+
+// Describe a recipe using the names of its items
+func (recipe *Recipe) format() string {
+	names := make([]string, 0, len(recipe.Ingredients))
+	for _, ingredient := range recipe.Ingredients {
+		names = append(names, itemCatalog.get(ingredient).Name)
+	}
+	return strings.Join(names, " + ") + " = " + itemCatalog.get(recipe.Output).Name
+}
+
+type RecipeBook struct {
+	recipes []*Recipe
+	byID    map[string]*Recipe
+	byKey   map[string]*Recipe
+}
+
+var recipeBook = NewRecipeBook()
+
+func NewRecipeBook() *RecipeBook {
+	book := new(RecipeBook)
+	book.byID = make(map[string]*Recipe)
+	book.byKey = make(map[string]*Recipe)
+	return book
+}
+
+// Read recipes from a JSON file checking every item they use exists within the catalog
+func LoadRecipeBook(path string, catalog *ItemCatalog) (*RecipeBook, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var recipes []*Recipe
+	if err := json.Unmarshal(content, &recipes); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	book := NewRecipeBook()
+	var problems []string
+
+	for i, recipe := range recipes {
+		if recipe.ID == "" || recipe.Output == "" || len(recipe.Ingredients) < 2 {
+			problems = append(problems, fmt.Sprintf("%s: recipe %d must have an id, an output and at least two ingredients", path, i))
+			continue
+		}
+
+		valid := true
+		for _, id := range append([]string{recipe.Output}, recipe.Ingredients...) {
+			if catalog.get(id) == nil {
+				problems = append(problems, path+": recipe "+recipe.ID+" uses unknown item "+id)
+				valid = false
+			}
+		}
+
+		if _, ok := book.byID[recipe.ID]; ok {
+			problems = append(problems, path+": recipe "+recipe.ID+" is defined twice")
+			valid = false
+		}
+		if other, ok := book.byKey[recipe.key()]; ok {
+			problems = append(problems, path+": recipes "+other.ID+" and "+recipe.ID+" use the same ingredients")
+			valid = false
+		}
+
+		if valid {
+			book.recipes = append(book.recipes, recipe)
+			book.byID[recipe.ID] = recipe
+			book.byKey[recipe.key()] = recipe
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+
+	return book, nil
+}
+
+// Find the recipe which uses exactly these ingredients (nil if there is none)
+func (book *RecipeBook) find(ingredients []string) *Recipe {
+	recipe := Recipe{Ingredients: ingredients}
+	return book.byKey[recipe.key()]
+}
+
+/*
+Signature `combine {item1} {item2} ...`
+Craft a new item from ingredients within the inventory, discovering the recipe the first time it is made
+*/
+func (player *Player) combine(modifiers []string) {
+	// Work out which items were named asking the player to choose whenever a name is ambiguous
+	names := splitItemNames(modifiers, player.inventory)
+
+	for i, query := range names {
+		i := i
+		itemIndex := player.selectItem(player.inventory, query, "You do not possess "+query, func(name string) {
+			resolved := append([]string(nil), names...)
+			resolved[i] = name
+			player.combine(resolved)
+		})
+
+		if itemIndex < 0 {
+			return
+		}
+
+		names[i] = player.inventory[itemIndex].description
+	}
+
+	if len(names) < 2 {
+		player.displayError("You must combine at least two items")
+		return
+	}
+
+	// Every ingredient must be held (as many times as it is named)
+	var ingredients []string
+	needed := make(map[string]int)
+
+	for _, name := range names {
+		_, item := Find(player.inventory, func(item Item) bool {
+			return item.description == name
+		})
+
+		// Items which do not stack are held as separate entries so count every one
+		held := Reduce(player.inventory, func(item Item, total int) int {
+			if item.description == name {
+				return total + item.quantity
+			}
+			return total
+		}, 0)
+
+		needed[name]++
+		if needed[name] > held {
+			player.displayError("You do not possess enough " + name)
+			return
+		}
+
+		ingredients = append(ingredients, item.id)
+	}
+
+	recipe := recipeBook.find(ingredients)
+	if recipe == nil {
+		player.displayError("Nothing comes of combining " + strings.Join(names, " and "))
+		return
+	}
+
+	// Remove each ingredient from the inventory (unequipping anything which is used up)
+	for _, name := range names {
+		itemIndex, _ := Find(player.inventory, func(item Item) bool {
+			return item.description == name
+		})
+
+		if player.weapon != nil && player.weapon.description == name {
+			player.weapon = nil
+		}
+
+		if player.armour != nil && player.armour.description == name {
+			player.armour = nil
+		}
+
+		player.takeFromInventory(itemIndex)
+	}
+
+	output := itemCatalog.newItem(itemCatalog.get(recipe.Output), *player.coordinates)
+	player.addToInventory(output)
+
+	player.writeCompact("Combined " + strings.Join(names, " and ") + " to create " + output.description)
+
+	if !player.recipes[recipe.ID] {
+		player.recipes[recipe.ID] = true
+		player.writeCompact("You have discovered a new recipe: " + recipe.format())
+	}
+
+	player.writeCompact("")
+}
+
+/*
+Signature `recipes`
+Lists the recipes a player has discovered
+*/
+func (player *Player) listRecipes(modifiers []string) {
+	if len(player.recipes) == 0 {
+		player.write("You have not discovered any recipes - try combining items")
+		return
+	}
+
+	player.writeCompact("Recipes you have discovered:")
+	for _, recipe := range recipeBook.recipes {
+		if player.recipes[recipe.ID] {
+			player.writeCompact(recipe.format())
+		}
+	}
+
+	player.write(fmt.Sprintf("%d of %d recipes discovered", len(player.recipes), len(recipeBook.recipes)))
+}
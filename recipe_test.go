@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestCombineCountsSeparateEntries(t *testing.T) {
+	world := getWorldInstance()
+	player := newTestPlayer(t, "crafter")
+
+	recipe := &Recipe{ID: "double_torch", Ingredients: []string{"torch", "torch"}, Output: "lantern"}
+	book := NewRecipeBook()
+	book.recipes = append(book.recipes, recipe)
+	book.byID[recipe.ID] = recipe
+	book.byKey[recipe.key()] = recipe
+
+	world.execute(func() {
+		previous := recipeBook
+		recipeBook = book
+		defer func() {
+			recipeBook = previous
+		}()
+
+		// Torches do not stack so two are held as separate entries
+		torch := itemCatalog.newItem(itemCatalog.get("torch"), Point{})
+		player.inventory = []Item{torch, torch}
+
+		player.handleInput([]string{"combine", "torch", "torch"})
+
+		if len(player.inventory) != 1 || player.inventory[0].id != "lantern" {
+			t.Errorf("expected two torches to be combined into a lantern but the inventory holds %v", player.inventory)
+			return
+		}
+
+		if !player.recipes[recipe.ID] {
+			t.Error("expected the recipe to be discovered")
+		}
+
+		// A single torch is not enough for a recipe needing two
+		player.inventory = []Item{torch}
+		player.handleInput([]string{"combine", "torch", "torch"})
+
+		if len(player.inventory) != 1 || player.inventory[0].id != "torch" {
+			t.Errorf("expected a single torch to be left alone but the inventory holds %v", player.inventory)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// A single jump along a route between towns
+type Hop struct {
+	direction int // Index within adjacentTowns [North, South, East, West]
+	town      *Town
+}
+
+/*
+Find the fewest jumps from one town to another with a breadth first search over adjoining towns
+Only links with a gate are followed so every hop can be jumped
+Returns an empty route if they are the same town and nil if there is no way between them
+*/
+func findRoute(from *Town, to *Town) []Hop {
+	if from == to {
+		return []Hop{}
+	}
+
+	previous := map[*Town]Hop{from: {}}
+	queue := []*Town{from}
+
+	for len(queue) > 0 {
+		town := queue[0]
+		queue = queue[1:]
+
+		for direction, adjacentTown := range town.adjacentTowns {
+			if adjacentTown == nil || town.gates[direction] == nil {
+				continue
+			}
+			if _, seen := previous[adjacentTown]; seen {
+				continue
+			}
+
+			previous[adjacentTown] = Hop{direction, town}
+
+			if adjacentTown == to {
+				return buildRoute(previous, from, to)
+			}
+
+			queue = append(queue, adjacentTown)
+		}
+	}
+
+	return nil
+}
+
+// Walk back from the destination to the start (previous stores the town each was reached from)
+func buildRoute(previous map[*Town]Hop, from *Town, to *Town) []Hop {
+	var route []Hop
+
+	for town := to; town != from; town = previous[town].town {
+		route = append([]Hop{{previous[town].direction, town}}, route...)
+	}
+
+	return route
+}
+
+// Search the world for a town by its name ignoring case (nil if there is no such town)
+func (world *World) findTownByName(name string) *Town {
+	for _, town := range world.towns {
+		if strings.EqualFold(town.name, name) {
+			return town
+		}
+	}
+	return nil
+}
+
+// Work out the route to the town a player named (nil after explaining why if there is none)
+func (player *Player) planRoute(name string) []Hop {
+	destination := getWorldInstance().findTownByName(name)
+	if destination == nil {
+		player.displayError("There is no town called " + name)
+		return nil
+	}
+
+	if destination == player.currentTown {
+		player.displayError("You are already in " + destination.name)
+		return nil
+	}
+
+	route := findRoute(player.currentTown, destination)
+	if route == nil {
+		player.displayError("There is no way to reach " + destination.name + " from here")
+		return nil
+	}
+
+	return route
+}
+
+/*
+Signature `route {town}`
+Lists the jumps needed to reach any town
+*/
+func (player *Player) route(modifiers []string) {
+	route := player.planRoute(strings.Join(modifiers, " "))
+	if route == nil {
+		return
+	}
+
+	destination := route[len(route)-1].town
+	player.writeCompact("The route to " + destination.name + " takes " + formatJumps(len(route)) + ":")
+
+	// Routes only follow links with a gate so each town along the way has one
+	town := player.currentTown
+	for i, hop := range route {
+		player.writeCompact(strconv.Itoa(i+1) + ": jump " + directionNames[hop.direction] + " to " + hop.town.name + " from the gate at " + town.gates[hop.direction].format())
+		town = hop.town
+	}
+
+	player.writeCompact("")
+}
+
+/*
+Signature `travel {town}`
+Walk to each gate along the route to a town and jump through it, each jump taking TRAVEL_TIME (any other command stops the journey)
+*/
+func (player *Player) travel(modifiers []string) {
+	route := player.planRoute(strings.Join(modifiers, " "))
+	if route == nil {
+		return
+	}
+
+	path := player.pathToGate(route[0])
+	if path == nil {
+		return
+	}
+
+	destination := route[len(route)-1].town
+	player.write("You set off for " + destination.name + " which is " + formatJumps(len(route)) + " away")
+	player.startJourney(path, route, destination.name)
+}
+
+// Path from the player to the gate leading to the town a hop reaches (nil after explaining why if there is none)
+func (player *Player) pathToGate(hop Hop) []Point {
+	gate := player.currentTown.gates[hop.direction]
+	if gate == nil {
+		player.displayError("There is no gate leading to " + hop.town.name)
+		return nil
+	}
+
+	path := findPath(player.currentTown.dungeonLayout, *player.coordinates, *gate)
+	if path == nil {
+		player.displayError("There is no way to reach the gate to " + hop.town.name)
+	}
+
+	return path
+}
+
+func formatJumps(jumps int) string {
+	if jumps == 1 {
+		return "1 jump"
+	}
+	return strconv.Itoa(jumps) + " jumps"
+}
@@ -0,0 +1,111 @@
+package main
+
+import "testing"
+
+// Towns in a row from west to east linked through gates upon their edges
+func newTownRow(names ...string) []*Town {
+	var towns []*Town
+	for _, name := range names {
+		towns = append(towns, newOpenTown(name))
+	}
+
+	for i := 1; i < len(towns); i++ {
+		towns[i-1].adjacentTowns[2] = towns[i]
+		towns[i].adjacentTowns[3] = towns[i-1]
+	}
+
+	for _, town := range towns {
+		town.placeGates(getWorldInstance().rng)
+	}
+
+	return towns
+}
+
+func TestRouteFindsFewestJumps(t *testing.T) {
+	towns := newTownRow("Westmere", "Midvale", "Eastholm")
+
+	// A longer way round through the north should never be preferred
+	northern := newOpenTown("Northby")
+	towns[0].adjacentTowns[0] = northern
+	northern.adjacentTowns[1] = towns[0]
+
+	route := findRoute(towns[0], towns[2])
+	if len(route) != 2 || route[0].town != towns[1] || route[1].town != towns[2] || route[0].direction != 2 {
+		t.Fatalf("expected to jump east twice but got %v", route)
+	}
+
+	if route := findRoute(towns[1], towns[1]); route == nil || len(route) != 0 {
+		t.Fatalf("expected an empty route to the same town but got %v", route)
+	}
+}
+
+func TestRouteSkipsLinksWithoutGates(t *testing.T) {
+	towns := newTownRow("Westmere", "Eastholm")
+	towns[0].gates[2] = nil
+
+	if route := findRoute(towns[0], towns[1]); route != nil {
+		t.Fatalf("expected no route through a link without a gate but got %v", route)
+	}
+}
+
+func TestJumpWithoutGates(t *testing.T) {
+	world := getWorldInstance()
+	player := newTestPlayer(t, "jumper")
+
+	world.execute(func() {
+		towns := newTownRow("Westmere", "Eastholm")
+
+		// Without a gate to stand upon the player stays where they are
+		towns[0].gates[2] = nil
+		player.currentTown = towns[0]
+		player.handleInput([]string{"jump", "east"})
+
+		if player.currentTown != towns[0] {
+			t.Error("expected the player not to jump without a gate")
+		}
+
+		// A link which does not lead back has no gate to arrive at so the player lands upon any walkable tile
+		towns = newTownRow("Westmere", "Eastholm")
+		towns[1].gates[3] = nil
+		towns[1].dungeonLayout = newLayout(config.Width, config.Height)
+		towns[1].dungeonLayout[3][4] = 1
+
+		player.currentTown = towns[0]
+		player.coordinates = NewPoint(towns[0].gates[2].x, towns[0].gates[2].y)
+		player.handleInput([]string{"jump", "east"})
+
+		if player.currentTown != towns[1] {
+			t.Error("expected the player to jump east")
+			return
+		}
+
+		if *player.coordinates != (Point{3, 4}) {
+			t.Errorf("expected the player to land upon the only walkable tile but landed at %s", player.coordinates.format())
+		}
+	})
+}
+
+func TestJumpArrivesAtGateLeadingBack(t *testing.T) {
+	world := getWorldInstance()
+	player := newTestPlayer(t, "arriver")
+
+	world.execute(func() {
+		towns := newTownRow("Westmere", "Eastholm")
+
+		player.currentTown = towns[0]
+		player.coordinates = NewPoint(0, 0)
+		player.handleInput([]string{"jump", "east"})
+
+		if player.currentTown != towns[0] {
+			t.Error("expected the player to need to stand upon the gate to jump")
+			return
+		}
+
+		player.coordinates = NewPoint(towns[0].gates[2].x, towns[0].gates[2].y)
+		player.handleInput([]string{"jump", "east"})
+
+		if player.currentTown != towns[1] || *player.coordinates != *towns[1].gates[3] {
+			t.Errorf("expected the player to arrive upon the gate leading back at %s but was at %s", towns[1].gates[3].format(), player.coordinates.format())
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const SHUTDOWN_TIMEOUT = 10 * time.Second // Longest time to wait for players to be saved when shutting down
+
+/*
+Server accepts connections and keeps track of them so they can be closed cleanly when it shuts down
+*/
+type Server struct {
+	listener    net.Listener
+	mutex       sync.Mutex
+	connections map[*TelnetConn]bool
+	closing     bool
+	handlers    sync.WaitGroup // Running handleConnection goroutines
+}
+
+func NewServer(address string) (*Server, error) {
+	listener, err := net.Listen("tcp", address) // Create a new server
+	if err != nil {
+		return nil, err
+	}
+
+	s := new(Server)
+	s.listener = listener
+	s.connections = make(map[*TelnetConn]bool)
+	return s, nil
+}
+
+// Accept connections until the server is closed
+func (server *Server) serve() {
+	fmt.Println("GUD running on port " + server.listener.Addr().String())
+
+	for {
+		rawConn, err := server.listener.Accept()
+		if err != nil {
+			if server.isClosing() || errors.Is(err, net.ErrClosed) {
+				return
+			}
+
+			// Errors such as running out of file descriptors pass so keep accepting after a short rest
+			fmt.Println("Failed to accept connection:", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		conn := NewTelnetConn(rawConn)
+
+		server.mutex.Lock()
+		server.connections[conn] = true
+		server.handlers.Add(1)
+		server.mutex.Unlock()
+
+		go func() {
+			defer server.handlers.Done()
+			defer server.forget(conn)
+
+			handleConnection(conn)
+		}()
+	}
+}
+
+func (server *Server) forget(conn *TelnetConn) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	delete(server.connections, conn)
+}
+
+func (server *Server) isClosing() bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return server.closing
+}
+
+// Stop accepting connections which causes serve to return
+func (server *Server) close() {
+	server.mutex.Lock()
+	server.closing = true
+	server.mutex.Unlock()
+
+	server.listener.Close()
+}
+
+/*
+Warn every player, disconnect them (which saves them) and then save the world
+Connections are closed only once the warning has been sent to them
+Must be called after serve has returned
+*/
+func (server *Server) shutdown(world *World) error {
+	world.broadcast("The server is shutting down - your progress will be saved. Farewell!")
+
+	server.mutex.Lock()
+	connections := GetKeys(server.connections)
+	server.mutex.Unlock()
+
+	for _, conn := range connections {
+		conn.Close()
+	}
+
+	// Wait for every connection to finish saving its player
+	deadline := time.Now().Add(SHUTDOWN_TIMEOUT)
+
+	finished := make(chan struct{})
+	go func() {
+		server.handlers.Wait()
+		close(finished)
+	}()
+
+	if !waitUntil(finished, deadline) {
+		fmt.Println("Timed out waiting for players to be saved")
+	}
+
+	// Players may have finished before everything written to them has been sent
+	for _, conn := range connections {
+		if !waitUntil(conn.flushed, deadline) {
+			fmt.Println("Timed out waiting for players to be sent their farewell")
+			break
+		}
+	}
+
+	if err := world.save(config.WorldFile); err != nil {
+		return err
+	}
+
+	fmt.Println("World saved to", config.WorldFile)
+	return nil
+}
+
+// Wait for done to be closed returning false if the deadline passes first
+func waitUntil(done <-chan struct{}, deadline time.Time) bool {
+	select {
+	case <-done:
+		return true
+	case <-time.After(time.Until(deadline)):
+		return false
+	}
+}
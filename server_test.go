@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Read from the server until text arrives
+func expect(t *testing.T, reader *bufio.Reader, text string) {
+	t.Helper()
+
+	var received strings.Builder
+	for !strings.Contains(received.String(), text) {
+		c, err := reader.ReadByte()
+		if err != nil {
+			t.Fatalf("expected %q but only received %q before %v", text, received.String(), err)
+		}
+		received.WriteByte(c)
+	}
+}
+
+func TestShutdownWarnsPlayers(t *testing.T) {
+	previousStore, previousWorldFile := accountStore, config.WorldFile
+	accountStore = NewAccountStore(t.TempDir())
+	config.WorldFile = filepath.Join(t.TempDir(), "world.json")
+	defer func() {
+		accountStore, config.WorldFile = previousStore, previousWorldFile
+	}()
+
+	server, err := NewServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	served := make(chan struct{})
+	go func() {
+		server.serve()
+		close(served)
+	}()
+
+	client, err := net.Dial("tcp", server.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetDeadline(time.Now().Add(10 * time.Second))
+
+	// Create an account so the player is logged into the world
+	reader := bufio.NewReader(client)
+	steps := []struct{ prompt, answer string }{
+		{"By what do you wish to be addressed by?", "Warned"},
+		{"would you like to join?", "yes"},
+		{"Choose a password:", "secret"},
+		{"Repeat your password:", "secret"},
+	}
+
+	for _, step := range steps {
+		expect(t, reader, step.prompt)
+		if _, err := client.Write([]byte(step.answer + "\r\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect(t, reader, "Welcome to GUD!")
+
+	server.close()
+	<-served
+
+	if err := server.shutdown(getWorldInstance()); err != nil {
+		t.Fatal(err)
+	}
+
+	received, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(received), "The server is shutting down") {
+		t.Fatalf("expected the player to be warned before being disconnected but received %q", received)
+	}
+}
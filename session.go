@@ -0,0 +1,89 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Session records how long a player has been connected and when they last entered a command
+type Session struct {
+	player      *Player
+	connectedAt time.Time
+	lastInput   time.Time
+}
+
+/*
+SessionManager tracks every player who has logged in
+Other subsystems use it to find or message a player by name
+It is owned by the game loop so must only be used from commands it executes
+*/
+type SessionManager struct {
+	sessions map[string]*Session // Keyed by the lower case name of the player
+}
+
+func NewSessionManager() *SessionManager {
+	m := new(SessionManager)
+	m.sessions = make(map[string]*Session)
+	return m
+}
+
+func (manager *SessionManager) add(player *Player) {
+	now := time.Now()
+	manager.sessions[strings.ToLower(player.name)] = &Session{player, now, now}
+}
+
+func (manager *SessionManager) remove(player *Player) {
+	delete(manager.sessions, strings.ToLower(player.name))
+}
+
+// Record that a player has just entered a command
+func (manager *SessionManager) touch(player *Player) {
+	if session, ok := manager.sessions[strings.ToLower(player.name)]; ok {
+		session.lastInput = time.Now()
+	}
+}
+
+// Find a player who is logged in by their name (nil if nobody of that name is online)
+func (manager *SessionManager) find(name string) *Player {
+	if session, ok := manager.sessions[strings.ToLower(name)]; ok {
+		return session.player
+	}
+	return nil
+}
+
+// Send a message to a player by name, returning false if they are not online
+func (manager *SessionManager) message(name string, text string) bool {
+	player := manager.find(name)
+	if player == nil {
+		return false
+	}
+
+	player.write(text)
+	return true
+}
+
+// Retrieve every session ordered by the name of the player
+func (manager *SessionManager) all() []*Session {
+	sessions := GetValues(manager.sessions)
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return strings.ToLower(sessions[i].player.name) < strings.ToLower(sessions[j].player.name)
+	})
+
+	return sessions
+}
+
+// Retrieve every player who is logged in
+func (manager *SessionManager) players() []*Player {
+	players := make([]*Player, 0, len(manager.sessions))
+	for _, session := range manager.all() {
+		players = append(players, session.player)
+	}
+	return players
+}
+
+// Shorten a duration to whole seconds so it reads nicely
+func formatDuration(duration time.Duration) string {
+	return duration.Truncate(time.Second).String()
+}
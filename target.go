@@ -0,0 +1,175 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var coordinatesRegex = regexp.MustCompile(`^\[?\s*(\d+)\s*[, ]\s*(\d+)\s*\]?$`)
+
+// Words which pick out a whole kind of thing rather than something by name
+var targetKinds = map[string]func(player *Player) []Target{
+	"enemy":   eventsOfType(Enemy),
+	"enemies": eventsOfType(Enemy),
+	"foe":     eventsOfType(Enemy),
+	"npc":     eventsOfType(NPC),
+	"npcs":    eventsOfType(NPC),
+	"trader":  eventsOfType(NPC),
+	"hotspot": eventsOfType(Hotspot),
+	"food":    itemsOfType(Food),
+	"weapon":  itemsOfType(Weapon),
+	"armour":  itemsOfType(Armour),
+	"player":  func(player *Player) []Target { return playerTargets(player, "") },
+	"gate":    func(player *Player) []Target { return gateTargets(player, "") },
+	"gates":   func(player *Player) []Target { return gateTargets(player, "") },
+}
+
+// Targets are places within a town a player can find their way to
+type Target struct {
+	coordinates Point
+	name        string
+	path        []Point // Shortest path from the player (empty if they are standing upon it)
+}
+
+// Read coordinates written as `x y`, `x,y` or `[x,y]`
+func parseCoordinates(query string) (*Point, bool) {
+	match := coordinatesRegex.FindStringSubmatch(strings.TrimSpace(query))
+	if match == nil {
+		return nil, false
+	}
+
+	x, errX := strconv.Atoi(match[1])
+	y, errY := strconv.Atoi(match[2])
+	if errX != nil || errY != nil || x >= config.Width || y >= config.Height {
+		return nil, false
+	}
+
+	return NewPoint(x, y), true
+}
+
+func eventsOfType(eventType EventType) func(player *Player) []Target {
+	return func(player *Player) []Target {
+		var targets []Target
+		for _, event := range player.currentTown.events {
+			if event.eventType == eventType {
+				targets = append(targets, Target{coordinates: event.coordinates, name: eventName(event)})
+			}
+		}
+		return targets
+	}
+}
+
+func itemsOfType(itemType ItemType) func(player *Player) []Target {
+	return func(player *Player) []Target {
+		var targets []Target
+		for _, item := range player.currentTown.items {
+			if item.itemType == itemType {
+				targets = append(targets, Target{coordinates: item.coordinates, name: item.description})
+			}
+		}
+		return targets
+	}
+}
+
+// Other players within the town (all of them if name is empty)
+func playerTargets(player *Player, name string) []Target {
+	var targets []Target
+	for _, other := range player.otherPlayersInTown() {
+		if name == "" || strings.EqualFold(other.name, name) {
+			targets = append(targets, Target{coordinates: *other.coordinates, name: other.name})
+		}
+	}
+	return targets
+}
+
+// Gates within the town leading to a town (all of them if name is empty)
+func gateTargets(player *Player, name string) []Target {
+	var targets []Target
+	for direction, gate := range player.currentTown.gates {
+		adjacentTown := player.currentTown.adjacentTowns[direction]
+		if gate != nil && adjacentTown != nil && (name == "" || strings.EqualFold(adjacentTown.name, name)) {
+			targets = append(targets, Target{coordinates: *gate, name: "the gate to " + adjacentTown.name})
+		}
+	}
+	return targets
+}
+
+func eventName(event *Event) string {
+	if event.name == "" {
+		return "a " + event.eventType.String()
+	}
+	return event.name
+}
+
+/*
+Work out where within the current town a player means and the nearest place it can be found
+A target may be coordinates, a player, an NPC or enemy by name, a neighbouring town (its gate), a kind of thing (enemy, npc, hotspot, gate, food...) or an item
+Leading words such as "nearest" are ignored as the nearest is always picked
+Returns nil (after explaining why or asking the player to choose between items) if there is nowhere to go yet
+*/
+func (player *Player) resolveTarget(query string, retry func(name string)) *Target {
+	words := strings.Fields(strings.ToLower(query))
+	for len(words) > 1 && (words[0] == "nearest" || words[0] == "closest" || words[0] == "the" || words[0] == "a") {
+		words = words[1:]
+	}
+	query = strings.Join(words, " ")
+
+	if point, ok := parseCoordinates(query); ok {
+		return player.nearestTarget([]Target{{coordinates: *point, name: point.format()}}, point.format())
+	}
+
+	candidates := append(playerTargets(player, query), gateTargets(player, query)...)
+
+	for _, event := range player.currentTown.events {
+		if event.name != "" && strings.EqualFold(event.name, query) {
+			candidates = append(candidates, Target{coordinates: event.coordinates, name: event.name})
+		}
+	}
+
+	if kind, ok := targetKinds[query]; ok {
+		candidates = append(candidates, kind(player)...)
+	}
+
+	// Items are only matched loosely when nothing else was found so the player may need to choose between them
+	items := player.currentTown.items
+	if len(candidates) == 0 {
+		itemIndex := player.selectItem(items, query, "There is nothing called "+query+" within "+player.currentTown.name, retry)
+		if itemIndex < 0 {
+			return nil
+		}
+		query = items[itemIndex].description
+	}
+
+	for _, item := range items {
+		if strings.EqualFold(item.description, query) {
+			candidates = append(candidates, Target{coordinates: item.coordinates, name: item.description})
+		}
+	}
+
+	return player.nearestTarget(candidates, query)
+}
+
+// Pick the candidate with the shortest path from the player (nil if none can be reached)
+func (player *Player) nearestTarget(candidates []Target, query string) *Target {
+	var reachable []Target
+
+	for _, candidate := range candidates {
+		candidate.path = findPath(player.currentTown.dungeonLayout, *player.coordinates, candidate.coordinates)
+		if candidate.path != nil {
+			reachable = append(reachable, candidate)
+		}
+	}
+
+	if len(reachable) == 0 {
+		player.displayError("There is no way to reach " + query)
+		return nil
+	}
+
+	sort.SliceStable(reachable, func(i, j int) bool {
+		return len(reachable[i].path) < len(reachable[j].path)
+	})
+
+	return &reachable[0]
+}
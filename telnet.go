@@ -0,0 +1,371 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	MAX_OUTBOUND       = 16 * 1024 * 1024 // Most bytes which may wait to be sent (many times the largest map so only a client queueing output without reading reaches it)
+	WRITE_TIMEOUT      = 10 * time.Second // Longest a client may go without accepting any data before it is dropped
+	MAX_SUBNEGOTIATION = 64               // Most bytes kept from a subnegotiation (anything longer is ignored)
+)
+
+var errSlowClient = errors.New("client is not reading what is sent to it")
+
+// Telnet commands and options (RFC 854, 857, 1073 and 1091)
+const (
+	IAC  byte = 255 // Interpret as command
+	DONT byte = 254
+	DO   byte = 253
+	WONT byte = 252
+	WILL byte = 251
+	SB   byte = 250 // Start of subnegotiation
+	SE   byte = 240 // End of subnegotiation
+
+	ECHO  byte = 1
+	SGA   byte = 3 // Suppress go ahead
+	TTYPE byte = 24
+	NAWS  byte = 31
+
+	TTYPE_IS   byte = 0
+	TTYPE_SEND byte = 1
+)
+
+type telnetState int
+
+const (
+	telnetData telnetState = iota
+	telnetCommand
+	telnetOption
+	telnetSubnegotiation
+	telnetSubnegotiationCommand
+)
+
+/*
+TelnetConn sits between the connection and the rest of the game
+IAC sequences are removed from everything the client sends and answered so clients such as Mudlet or TinTin++
+never leak negotiation bytes into commands, whilst the window size (NAWS) and terminal type (TTYPE) are recorded
+Writes are queued and sent by a goroutine of their own so a client which stops reading never holds up the game loop
+*/
+type TelnetConn struct {
+	net.Conn
+
+	state                 telnetState
+	command               byte   // Negotiation command currently being read (DO, DONT, WILL, WONT)
+	subnegotiationData    []byte // Contents of the subnegotiation currently being read
+	subnegotiationDropped bool   // Subnegotiation grew beyond MAX_SUBNEGOTIATION and is skipped until it ends
+
+	mutex        sync.Mutex
+	width        int           // Window width reported through NAWS (0 if unknown)
+	height       int           // Window height reported through NAWS (0 if unknown)
+	terminalType string        // Terminal type reported through TTYPE
+	enabled      map[byte]bool // Options the server has agreed to perform
+
+	outboundMutex sync.Mutex
+	pending       []byte        // Data waiting to be sent by the writer goroutine
+	ready         chan struct{} // Wakes the writer goroutine when there is data to send or the connection is closing
+	closed        bool          // No more data is accepted once the connection is closing
+	flushed       chan struct{} // Closed by the writer goroutine once it has stopped sending and closed the connection
+	writeTimeout  time.Duration // Longest the client may go without accepting any data
+}
+
+func NewTelnetConn(conn net.Conn) *TelnetConn {
+	return newTelnetConn(conn, WRITE_TIMEOUT)
+}
+
+func newTelnetConn(conn net.Conn, writeTimeout time.Duration) *TelnetConn {
+	t := new(TelnetConn)
+	t.Conn = conn
+	t.enabled = make(map[byte]bool)
+	t.ready = make(chan struct{}, 1)
+	t.flushed = make(chan struct{})
+	t.writeTimeout = writeTimeout
+
+	go t.writeLoop()
+
+	// Ask the client to report its window size and terminal type
+	t.send([]byte{IAC, DO, NAWS, IAC, DO, TTYPE})
+
+	return t
+}
+
+// Read data from the client with all telnet commands removed
+func (t *TelnetConn) Read(b []byte) (int, error) {
+	for {
+		raw := make([]byte, len(b))
+		n, err := t.Conn.Read(raw)
+
+		data := t.process(raw[:n])
+		copy(b, data)
+
+		// Only return once there is data (or an error) so a pure negotiation packet is not mistaken for end of input
+		if len(data) > 0 || err != nil {
+			return len(data), err
+		}
+	}
+}
+
+// Write data to the client escaping any bytes which would be interpreted as commands
+func (t *TelnetConn) Write(b []byte) (int, error) {
+	escaped := make([]byte, 0, len(b))
+	for _, c := range b {
+		if c == IAC {
+			escaped = append(escaped, IAC)
+		}
+		escaped = append(escaped, c)
+	}
+
+	if err := t.send(escaped); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+/*
+Queue raw bytes to be sent to the client without waiting for them to be written
+Clients which stop reading are dropped by the writer goroutine, although one which keeps queueing output
+beyond MAX_OUTBOUND before then is dropped straight away so it cannot use up the server's memory
+*/
+func (t *TelnetConn) send(data []byte) error {
+	t.outboundMutex.Lock()
+	defer t.outboundMutex.Unlock()
+
+	if t.closed {
+		return net.ErrClosed
+	}
+
+	if len(t.pending)+len(data) > MAX_OUTBOUND {
+		t.closed = true
+		t.pending = nil
+		t.Conn.Close()
+		return errSlowClient
+	}
+
+	t.pending = append(t.pending, data...)
+	t.wake()
+	return nil
+}
+
+// Let the writer goroutine know there is something to do (without waiting if it already knows)
+func (t *TelnetConn) wake() {
+	select {
+	case t.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Send queued data to the client until the connection is closed
+func (t *TelnetConn) writeLoop() {
+	defer close(t.flushed)
+
+	for range t.ready {
+		t.outboundMutex.Lock()
+		data, closed := t.pending, t.closed
+		t.pending = nil
+		t.outboundMutex.Unlock()
+
+		if len(data) > 0 {
+			if err := t.flush(data); err != nil {
+				t.outboundMutex.Lock()
+				t.closed = true
+				t.pending = nil
+				t.outboundMutex.Unlock()
+
+				t.Conn.Close()
+				return
+			}
+		}
+
+		if closed {
+			t.Conn.Close()
+			return
+		}
+	}
+}
+
+// Write data to the client taking as long as needed providing the client never goes writeTimeout without accepting any
+func (t *TelnetConn) flush(data []byte) error {
+	for len(data) > 0 {
+		t.Conn.SetWriteDeadline(time.Now().Add(t.writeTimeout))
+		n, err := t.Conn.Write(data)
+		data = data[n:]
+
+		// A deadline passing after some data was accepted only means the client is reading slowly
+		if err != nil && !(n > 0 && errors.Is(err, os.ErrDeadlineExceeded)) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close the connection once whatever is queued has been sent (without waiting for it to be)
+func (t *TelnetConn) Close() error {
+	t.outboundMutex.Lock()
+	defer t.outboundMutex.Unlock()
+
+	t.closed = true
+	t.wake()
+	return nil
+}
+
+// Run raw bytes through the telnet state machine returning the data which remains
+func (t *TelnetConn) process(raw []byte) []byte {
+	data := make([]byte, 0, len(raw))
+
+	for _, c := range raw {
+		switch t.state {
+		case telnetData:
+			if c == IAC {
+				t.state = telnetCommand
+			} else {
+				data = append(data, c)
+			}
+		case telnetCommand:
+			switch c {
+			case IAC:
+				// Escaped 255 is data
+				data = append(data, c)
+				t.state = telnetData
+			case DO, DONT, WILL, WONT:
+				t.command = c
+				t.state = telnetOption
+			case SB:
+				t.subnegotiationData = t.subnegotiationData[:0]
+				t.subnegotiationDropped = false
+				t.state = telnetSubnegotiation
+			default:
+				// Single byte commands (NOP, GA, AYT etc) carry no meaning here
+				t.state = telnetData
+			}
+		case telnetOption:
+			t.negotiate(t.command, c)
+			t.state = telnetData
+		case telnetSubnegotiation:
+			if c == IAC {
+				t.state = telnetSubnegotiationCommand
+			} else {
+				t.appendSubnegotiation(c)
+			}
+		case telnetSubnegotiationCommand:
+			if c == SE {
+				if !t.subnegotiationDropped {
+					t.subnegotiate(t.subnegotiationData)
+				}
+				t.state = telnetData
+			} else {
+				t.appendSubnegotiation(c)
+				t.state = telnetSubnegotiation
+			}
+		}
+	}
+
+	return data
+}
+
+// Record a byte of the current subnegotiation, dropping it entirely once it grows too long for a client to be trusted
+func (t *TelnetConn) appendSubnegotiation(c byte) {
+	if t.subnegotiationDropped {
+		return
+	}
+
+	if len(t.subnegotiationData) >= MAX_SUBNEGOTIATION {
+		t.subnegotiationData = nil
+		t.subnegotiationDropped = true
+		return
+	}
+
+	t.subnegotiationData = append(t.subnegotiationData, c)
+}
+
+// Answer a request from the client to enable or disable an option
+func (t *TelnetConn) negotiate(command byte, option byte) {
+	switch command {
+	case WILL:
+		switch option {
+		case TTYPE:
+			t.send([]byte{IAC, SB, TTYPE, TTYPE_SEND, IAC, SE})
+		case NAWS:
+			// Client will now send its window size through subnegotiation
+		default:
+			t.send([]byte{IAC, DONT, option})
+		}
+	case DO:
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+
+		// Only options the server has offered itself can be performed, and agreements are never repeated
+		if option != ECHO && option != SGA {
+			t.send([]byte{IAC, WONT, option})
+		} else if !t.enabled[option] {
+			t.enabled[option] = true
+			t.send([]byte{IAC, WILL, option})
+		}
+	case DONT:
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+
+		if t.enabled[option] {
+			t.enabled[option] = false
+			t.send([]byte{IAC, WONT, option})
+		}
+	}
+}
+
+// Handle the contents of a subnegotiation (NAWS window size or TTYPE terminal type)
+func (t *TelnetConn) subnegotiate(subnegotiation []byte) {
+	if len(subnegotiation) == 0 {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	switch subnegotiation[0] {
+	case NAWS:
+		if len(subnegotiation) == 5 {
+			t.width = int(subnegotiation[1])<<8 | int(subnegotiation[2])
+			t.height = int(subnegotiation[3])<<8 | int(subnegotiation[4])
+		}
+	case TTYPE:
+		if len(subnegotiation) > 1 && subnegotiation[1] == TTYPE_IS {
+			t.terminalType = string(subnegotiation[2:])
+		}
+	}
+}
+
+// Retrieve the size of the client's window (0 if it never reported one)
+func (t *TelnetConn) windowSize() (int, int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.width, t.height
+}
+
+func (t *TelnetConn) getTerminalType() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.terminalType
+}
+
+/*
+Toggle whether the client echoes what is typed
+The server claims the ECHO option (and then never echoes) to hide input such as passwords
+*/
+func (t *TelnetConn) setEcho(echo bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if echo {
+		t.enabled[ECHO] = false
+		t.send([]byte{IAC, WONT, ECHO})
+	} else {
+		t.enabled[ECHO] = true
+		t.send([]byte{IAC, WILL, ECHO})
+	}
+}
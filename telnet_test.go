@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTelnetWriteDoesNotWaitForClient(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	conn := NewTelnetConn(server)
+	defer conn.Close()
+
+	// Nothing reads from the client so every write must still return straight away
+	done := make(chan error)
+	go func() {
+		line := bytes.Repeat([]byte("x"), 1024*1024)
+		// The writer goroutine may already have taken some of it away to send
+		for written := 0; written <= 3*MAX_OUTBOUND; written += len(line) {
+			if _, err := conn.Write(line); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		if err != errSlowClient {
+			t.Fatalf("expected the client to be dropped once it let too much build up, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("writing to a client which is not reading blocked")
+	}
+
+	if _, err := conn.Write([]byte("more")); err == nil {
+		t.Fatal("expected writes to a dropped client to fail")
+	}
+}
+
+func TestTelnetDropsStalledClient(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	conn := newTelnetConn(server, 50*time.Millisecond)
+	conn.Write([]byte("Is anybody there?"))
+
+	select {
+	case <-conn.flushed:
+	case <-time.After(time.Second):
+		t.Fatal("expected a client which accepts nothing to be dropped")
+	}
+
+	if _, err := conn.Write([]byte("more")); err == nil {
+		t.Fatal("expected writes to a dropped client to fail")
+	}
+}
+
+func TestTelnetKeepsSlowReader(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	// Sending everything takes far longer than the write timeout but the client never stops reading
+	conn := newTelnetConn(server, 50*time.Millisecond)
+	output := bytes.Repeat([]byte("x"), 1024*1024)
+	if _, err := conn.Write(output); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	var received []byte
+	buffer := make([]byte, 4096)
+	for {
+		n, err := client.Read(buffer)
+		received = append(received, buffer[:n]...)
+		if err != nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if !bytes.HasSuffix(received, output) {
+		t.Fatalf("expected all %d bytes to be sent to a slow reader but received %d", len(output), len(received))
+	}
+}
+
+func TestTelnetCloseSendsQueuedData(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	conn := NewTelnetConn(server)
+	conn.Write([]byte("Farewell"))
+	conn.Close()
+
+	received, err := io.ReadAll(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasSuffix(received, []byte("Farewell")) {
+		t.Fatalf("expected queued data to be sent before closing, got %q", received)
+	}
+}
+
+func TestTelnetRemovesNegotiation(t *testing.T) {
+	conn := &TelnetConn{enabled: make(map[byte]bool), ready: make(chan struct{}, 1)}
+
+	data := conn.process([]byte{'l', IAC, SB, NAWS, 0, 80, 0, 24, IAC, SE, 'o', IAC, IAC, 'k'})
+	if !bytes.Equal(data, []byte{'l', 'o', IAC, 'k'}) {
+		t.Fatalf("unexpected data %v", data)
+	}
+
+	if width, height := conn.windowSize(); width != 80 || height != 24 {
+		t.Fatalf("expected an 80x24 window, got %dx%d", width, height)
+	}
+}
+
+func TestTelnetDropsLongSubnegotiation(t *testing.T) {
+	conn := &TelnetConn{enabled: make(map[byte]bool), ready: make(chan struct{}, 1)}
+
+	// A terminal type far longer than any real one is thrown away without being stored
+	raw := []byte{IAC, SB, TTYPE, TTYPE_IS}
+	raw = append(raw, bytes.Repeat([]byte{'x'}, 10*MAX_SUBNEGOTIATION)...)
+	raw = append(raw, IAC, SE, 'o', 'k')
+
+	data := conn.process(raw)
+	if !bytes.Equal(data, []byte{'o', 'k'}) {
+		t.Fatalf("unexpected data %v", data)
+	}
+
+	if conn.terminalType != "" || cap(conn.subnegotiationData) > MAX_SUBNEGOTIATION {
+		t.Fatalf("expected the subnegotiation to be dropped but kept %d bytes", cap(conn.subnegotiationData))
+	}
+
+	// Subnegotiations which follow are still understood
+	conn.process([]byte{IAC, SB, NAWS, 0, 80, 0, 24, IAC, SE})
+	if width, height := conn.windowSize(); width != 80 || height != 24 {
+		t.Fatalf("expected an 80x24 window, got %dx%d", width, height)
+	}
+}
@@ -10,112 +10,133 @@ import (
 
 // World simply consists of all of the rooms put together
 type World struct {
-	towns []Town // Slice of towns
+	towns    []*Town          // Slice of towns shared by every player
+	commands chan func()      // Queue of commands processed by the game loop
+	sessions *SessionManager  // Players who have logged in
+	channels map[string]*ChatChannel
+	seed     int64            // Seed the world was generated from
+	rng      *rand.Rand       // Source of randomness for generation and afterwards the game loop
+	saving   sync.Mutex       // Held whilst the world or its players are being saved so saves never overlap or go out of order
 }
 
 /*
 Singleton restricts instaniation of struct to a single instance
 Singletons also provide a global access to an instance and protects it from being overwritten
 */
-var once sync.Once
 var worldInstance *World
 
-func getWorldInstance() *World {
-	if worldInstance == nil {
-		once.Do(
-			func() {
-				worldInstance = NewWorld()
-			})
+/*
+Load the world (generating it if none has been saved) and start running it
+Must be called once before getWorldInstance so problems with the world are reported rather than panicking
+*/
+func startWorld() error {
+	world, err := loadOrCreateWorld(config.WorldFile, config.Seed)
+	if err != nil {
+		return err
 	}
 
+	worldInstance = world
+	go worldInstance.run()
+	go worldInstance.clock()
+	return nil
+}
+
+func getWorldInstance() *World {
 	return worldInstance
 }
 
-func NewWorld() *World {
+func NewWorld(seed int64) *World {
 	w := new(World)
+	w.commands = make(chan func())
+	w.sessions = NewSessionManager()
+	w.channels = make(map[string]*ChatChannel)
+	w.seed = seed
+	w.rng = rand.New(rand.NewSource(seed))
 
-	townNamesFile, _ := os.ReadFile("data/towns.txt")
+	townNamesFile, _ := os.ReadFile(config.dataPath("towns.txt"))
 	townNames := strings.Split(string(townNamesFile), "\n")
 	townName := "Unknown"
 
-	var towns []Town
+	var towns []*Town
 
-	for i := 0; i < randNumInRange(2, 6); i++ {
+	townCount := randNumInRange(w.rng, config.MinTowns, config.MaxTowns+1)
+	for i := 0; i < townCount; i++ {
 		// Create new room and add it to slice
-		townNames, townName = GetRandomAndRemove(townNames)
-		newTown := NewTown(townName)
-
-		towns = append(towns, *newTown)
+		townNames, townName = GetRandomAndRemove(w.rng, townNames)
+		towns = append(towns, NewTown(w.rng, townName))
+	}
 
-		// Pick room and make the new room adjacent to it - check it's not the newly created room
-		if (i - 1) >= 0 {
-			routeNum := rand.Intn(3)
-			oppRouteNum := 0
+	linkTowns(w.rng, towns)
 
-			switch routeNum {
-			case 0: {
-				oppRouteNum = 1
-			}
-			case 1: {
-				oppRouteNum = 0
-			}
-			case 2: {
-				oppRouteNum = 3
-			}
-			case 3: {
-				oppRouteNum = 2
-			}
-			}
-
-			newTown.adjacentTowns[oppRouteNum] = towns[1 - 1]
-			towns[i - 1].adjacentTowns[routeNum] = *newTown
-		}
+	for _, town := range towns {
+		town.placeGates(w.rng)
 	}
 
 	w.towns = towns
-	fmt.Println("Created", len(towns), "rooms")
+	if err := w.validate(); err != nil {
+		panic(err)
+	}
+
+	fmt.Println("Created", len(towns), "rooms from seed", seed)
 
 	return w
 }
 
+// Search the world for a town by its name (nil if there is no such town)
+func (world *World) findTown(name string) *Town {
+	for _, town := range world.towns {
+		if town.name == name {
+			return town
+		}
+	}
+	return nil
+}
+
 /*
 Town consists of a general dungeon layout, items, events, and adjacent rooms
 */
 type Town struct {
-	dungeonLayout [WIDTH][HEIGHT]int // Pixel array of map
+	dungeonLayout [][]int            // Pixel array of map indexed by [x][y]
 	items         []Item             // Slice of items
-	events        []Event            // Slice of events
+	events        []*Event           // Slice of events
+	corpses       []*Corpse          // Slice of corpses left by players who have died
 	name          string             // Name
-	adjacentTowns []Town             // Adjoining to this room in a specific direction [North, South, East, West]
+	adjacentTowns []*Town            // Adjoining to this room in a specific direction [North, South, East, West]
+	gates         [4]*Point          // Tiles which lead to each adjoining town (nil if there is no town that way)
 	description string
 }
 
-func NewTown(name string) *Town {
+func NewTown(rng *rand.Rand, name string) *Town {
 	r := new(Town)
 
 	r.name = name
-	r.adjacentTowns = make([]Town, 4)
+	r.adjacentTowns = make([]*Town, 4)
 
 	// Pick random description
-	descriptions, _ := os.ReadFile("data/townDescription.txt")
+	descriptions, _ := os.ReadFile(config.dataPath("townDescription.txt"))
 	townDescriptions := strings.Split(string(descriptions), "\n")
-	r.description = strings.Replace(townDescriptions[rand.Intn(len(townDescriptions))], "{}", r.name, -1)
+	r.description = strings.Replace(townDescriptions[rng.Intn(len(townDescriptions))], "{}", r.name, -1)
+
+	r.dungeonLayout = newLayout(config.Width, config.Height)
 
 	// Pick random start point within the array
-	var point = Point{WIDTH / 2, HEIGHT / 2, 0, 0, nil}
+	var point = Point{config.Width / 2, config.Height / 2}
+
+	lastDirection := pickPerpendicularRandomDirection(rng, "north")
 
-	lastDirection := pickPerpendicularRandomDirection("north")
+	// Players arrive at the start point so it must be walkable
+	r.dungeonLayout[point.x][point.y] = 1
 
 	// Generate a number of walks to make an actual dungeon
-	for i := 0; i < MAX_TUNNELS; i++ {
+	for i := 0; i < config.MaxTunnels; i++ {
 		/*
 			Pick random direction to walk which is perpendicular to the last direction
 			If last was right/left, new one must be up/down
 		*/
-		randomDirection := pickPerpendicularRandomDirection(lastDirection)
+		randomDirection := pickPerpendicularRandomDirection(rng, lastDirection)
 
 		// Calculate how long a tunnel will be
-		tunnelLength := rand.Intn(MAX_TUNNEL_LENGTH)
+		tunnelLength := rng.Intn(config.MaxTunnelLength)
 
 		for j := 0; j < tunnelLength; j++ {
 			getNewPoint(randomDirection, &point)
@@ -128,56 +149,59 @@ func NewTown(name string) *Town {
 		lastDirection = randomDirection
 	}
 
-	// Retrieve items which are predefined in a text file and add them into world
-	content, _ := os.ReadFile("data/items.txt")
-
-	itemNames := strings.Split(string(content), "\n")
-
-	for i := 0; i < randNumInRange(10, len(itemNames)); i++ {
-		itemType := Random
-
-		itemName := itemNames[i]
-
-		// Check type
-		if strings.Contains(itemName, "armour") {
-			itemType = Armour
-		} else if strings.Contains(itemName, "sword") || strings.Contains(itemName, "spear") {
-			itemType = Weapon
+	// Scatter items from the catalog around the town (rarer items turn up less often)
+	for i := 0; i < randNumInRange(rng, 10, 22); i++ {
+		definition := itemCatalog.random(rng, Random, Weapon, Armour)
+		if definition == nil {
+			break
 		}
 
-		// Push new item to global items array
-		r.items = append(r.items, Item{itemName, *findFreeLocationInDungeon(r.dungeonLayout), true, itemType})
+		r.items = append(r.items, itemCatalog.newItem(definition, *findFreeLocationInDungeon(rng, r.dungeonLayout)))
 	}
 
-	// Generate food items
-	for i := 0; i < randNumInRange(10, 20); i++ {
-		r.items = append(r.items, Item{itemNames[i], *findFreeLocationInDungeon(r.dungeonLayout), true, Food})
+	// Generate food items from the food within the catalog
+	for i := 0; i < randNumInRange(rng, 10, 20); i++ {
+		definition := itemCatalog.random(rng, Food)
+		if definition == nil {
+			break
+		}
+
+		r.items = append(r.items, itemCatalog.newItem(definition, *findFreeLocationInDungeon(rng, r.dungeonLayout)))
 	}
 
 	// Generate a random number of hotspots to be placed inside the world
-	for i := 0; i < randNumInRange(5, 15); i++ {
-		r.events = append(r.events, Event{*findFreeLocationInDungeon(r.dungeonLayout), Hotspot, ""})
+	for i := 0; i < randNumInRange(rng, 5, 15); i++ {
+		r.events = append(r.events, NewEvent(*findFreeLocationInDungeon(rng, r.dungeonLayout), Hotspot, ""))
 	}
 
 	// Generate NPC's from data files
-	npcNamesFile, _ := os.ReadFile("data/npcNames.txt")
+	npcNamesFile, _ := os.ReadFile(config.dataPath("npcNames.txt"))
 	npcNames := strings.Split(string(npcNamesFile), "\n")
 
-	for i := 0; i < randNumInRange(10, len(npcNames)); i++  {
-		r.events = append(r.events, Event{*findFreeLocationInDungeon(r.dungeonLayout), NPC, npcNames[rand.Intn(len(npcNames))]})
+	for i := 0; i < randNumInRange(rng, 10, len(npcNames)); i++  {
+		r.events = append(r.events, NewEvent(*findFreeLocationInDungeon(rng, r.dungeonLayout), NPC, npcNames[rng.Intn(len(npcNames))]))
 	}
 
 	// Generate enemies from data files
-	enemyNamesFile, _ := os.ReadFile("data/enemies.txt")
+	enemyNamesFile, _ := os.ReadFile(config.dataPath("enemies.txt"))
 	enemyNames := strings.Split(string(enemyNamesFile), "\n")
 
-	for i := 0; i < randNumInRange(10, 15); i++ {
-		r.events = append(r.events, Event{*findFreeLocationInDungeon(r.dungeonLayout), Enemy, enemyNames[rand.Intn(len(enemyNames))]})
+	for i := 0; i < randNumInRange(rng, 10, 15); i++ {
+		r.events = append(r.events, NewEnemy(rng, *findFreeLocationInDungeon(rng, r.dungeonLayout), enemyNames[rng.Intn(len(enemyNames))]))
 	}
 
 	return r
 }
 
+// Create an empty layout (all walls) for a map of the given size
+func newLayout(width int, height int) [][]int {
+	layout := make([][]int, width)
+	for x := range layout {
+		layout[x] = make([]int, height)
+	}
+	return layout
+}
+
 func (town *Town) checkAdjacentTown(direction string) (bool, string, int) {
 	switch direction {
 	case "north":
@@ -197,8 +221,12 @@ func (town *Town) getRoutes() []string {
 	var routes = make([]string, 0)
 
 	for i, adjacentTown := range town.adjacentTowns {
-		if adjacentTown.name != "" {
-			routes = append(routes, "You can go to " + adjacentTown.name + " which is " + convertToText(i))
+		if adjacentTown != nil {
+			if town.gates[i] == nil {
+				routes = append(routes, "You can see " + adjacentTown.name + " to the " + convertToText(i) + " but there is no gate leading there")
+			} else {
+				routes = append(routes, "You can go to " + adjacentTown.name + " which is " + convertToText(i) + " through the gate at " + town.gates[i].format())
+			}
 		}
 	}
 
@@ -206,7 +234,7 @@ func (town *Town) getRoutes() []string {
 }
 
 func (town *Town) checkEmptyTown(index int) (bool, string, int) {
-	if town.adjacentTowns[index].name == ""  {
+	if town.adjacentTowns[index] == nil {
 		return false, "No town that way m8", index
 	} else {
 		return true, "", index
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+)
+
+// Offsets of the neighbouring cells upon the grid towns are laid out on [North, South, East, West]
+var directionOffsets = [4]Point{{0, -1}, {0, 1}, {1, 0}, {-1, 0}}
+
+// Way each direction points within a town (matching move) [North, South, East, West]
+var gateDirections = [4]Point{{0, 1}, {0, -1}, {1, 0}, {-1, 0}}
+
+// Direction leading back the way a direction came [North, South, East, West]
+var oppositeDirections = [4]int{1, 0, 3, 2}
+
+/*
+Lay towns out upon a grid and link each to the towns beside it
+Every town after the first is placed in a free cell beside one already placed so all of them can be reached
+Links always lead both ways since a town to the north of another has that town to its south
+*/
+func linkTowns(rng *rand.Rand, towns []*Town) {
+	if len(towns) == 0 {
+		return
+	}
+
+	grid := map[Point]*Town{{0, 0}: towns[0]}
+	cells := []Point{{0, 0}}
+
+	for _, town := range towns[1:] {
+		// Cells beside any placed town which are still free (in a fixed order so seeds always give the same world)
+		var free []Point
+		for _, cell := range cells {
+			for _, offset := range directionOffsets {
+				neighbour := Point{cell.x + offset.x, cell.y + offset.y}
+				if _, taken := grid[neighbour]; !taken && !Contains(free, neighbour) {
+					free = append(free, neighbour)
+				}
+			}
+		}
+
+		cell := free[rng.Intn(len(free))]
+		grid[cell] = town
+		cells = append(cells, cell)
+
+		for direction, offset := range directionOffsets {
+			if neighbour, ok := grid[Point{cell.x + offset.x, cell.y + offset.y}]; ok {
+				town.adjacentTowns[direction] = neighbour
+				neighbour.adjacentTowns[oppositeDirections[direction]] = town
+			}
+		}
+	}
+}
+
+/*
+Place a gate for each adjoining town upon the walkable tile furthest in its direction
+Gates which have already been placed are kept and no two gates share a tile
+*/
+func (town *Town) placeGates(rng *rand.Rand) {
+	for direction, adjacentTown := range town.adjacentTowns {
+		if adjacentTown == nil || town.gates[direction] != nil {
+			continue
+		}
+
+		var furthest []Point
+		bestDistance := 0
+
+		for x := range town.dungeonLayout {
+			for y := range town.dungeonLayout[x] {
+				if town.dungeonLayout[x][y] != 1 || town.gateAt(Point{x, y}) >= 0 {
+					continue
+				}
+
+				distance := x*gateDirections[direction].x + y*gateDirections[direction].y
+				if len(furthest) == 0 || distance > bestDistance {
+					furthest = []Point{{x, y}}
+					bestDistance = distance
+				} else if distance == bestDistance {
+					furthest = append(furthest, Point{x, y})
+				}
+			}
+		}
+
+		if len(furthest) > 0 {
+			gate := furthest[rng.Intn(len(furthest))]
+			town.gates[direction] = &gate
+		}
+	}
+}
+
+// Direction of the gate upon a tile (-1 if there is none)
+func (town *Town) gateAt(point Point) int {
+	for direction, gate := range town.gates {
+		if gate != nil && *gate == point {
+			return direction
+		}
+	}
+	return -1
+}
+
+// Check every link between towns leads both ways through a gate and that every town can be reached from the first
+func (world *World) validate() error {
+	if len(world.towns) == 0 {
+		return errors.New("world contains no towns")
+	}
+
+	var problems []string
+
+	for _, town := range world.towns {
+		for direction, adjacentTown := range town.adjacentTowns {
+			if adjacentTown == nil {
+				continue
+			}
+
+			if adjacentTown.adjacentTowns[oppositeDirections[direction]] != town {
+				problems = append(problems, town.name+" leads "+directionNames[direction]+" to "+adjacentTown.name+" which does not lead back")
+			}
+
+			gate := town.gates[direction]
+			if gate == nil || gate.x < 0 || gate.y < 0 || gate.x >= config.Width || gate.y >= config.Height || town.dungeonLayout[gate.x][gate.y] != 1 {
+				problems = append(problems, town.name+" has no gate upon a walkable tile leading "+directionNames[direction])
+			}
+		}
+	}
+
+	for _, town := range world.towns[1:] {
+		if findRoute(world.towns[0], town) == nil {
+			problems = append(problems, town.name+" cannot be reached from "+world.towns[0].name)
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+
+	return nil
+}
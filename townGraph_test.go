@@ -0,0 +1,91 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+const TEST_SEEDS = 300
+
+// Check links lead both ways and every town can be reached from the first
+func checkTownGraph(t *testing.T, seed int64, towns []*Town) {
+	t.Helper()
+
+	for _, town := range towns {
+		for direction, adjacentTown := range town.adjacentTowns {
+			if adjacentTown != nil && adjacentTown.adjacentTowns[oppositeDirections[direction]] != town {
+				t.Fatalf("seed %d: %s leads %s to %s which does not lead back", seed, town.name, directionNames[direction], adjacentTown.name)
+			}
+		}
+	}
+
+	// Search the links alone (gates are checked separately)
+	reached := map[*Town]bool{towns[0]: true}
+	queue := []*Town{towns[0]}
+
+	for len(queue) > 0 {
+		town := queue[0]
+		queue = queue[1:]
+
+		for _, adjacentTown := range town.adjacentTowns {
+			if adjacentTown != nil && !reached[adjacentTown] {
+				reached[adjacentTown] = true
+				queue = append(queue, adjacentTown)
+			}
+		}
+	}
+
+	for _, town := range towns[1:] {
+		if !reached[town] {
+			t.Fatalf("seed %d: %s cannot be reached from %s", seed, town.name, towns[0].name)
+		}
+	}
+}
+
+func TestWorldsAreConnectedAcrossSeeds(t *testing.T) {
+	for seed := int64(1); seed <= TEST_SEEDS; seed++ {
+		world := NewWorld(seed)
+		checkTownGraph(t, seed, world.towns)
+
+		for _, town := range world.towns {
+			for direction, adjacentTown := range town.adjacentTowns {
+				if adjacentTown == nil {
+					continue
+				}
+
+				gate := town.gates[direction]
+				if gate == nil || town.dungeonLayout[gate.x][gate.y] != 1 {
+					t.Fatalf("seed %d: %s has no walkable gate leading %s", seed, town.name, directionNames[direction])
+				}
+			}
+		}
+
+		if err := world.validate(); err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+	}
+}
+
+func TestLargeTownGraphsAreConnected(t *testing.T) {
+	for seed := int64(1); seed <= TEST_SEEDS; seed++ {
+		var towns []*Town
+		for i := 0; i < 60; i++ {
+			towns = append(towns, &Town{name: "Town" + strconv.Itoa(i), adjacentTowns: make([]*Town, 4)})
+		}
+
+		linkTowns(rand.New(rand.NewSource(seed)), towns)
+		checkTownGraph(t, seed, towns)
+	}
+}
+
+func TestValidateFindsOneWayLinks(t *testing.T) {
+	first := newOpenTown("First")
+	second := newOpenTown("Second")
+	first.adjacentTowns[0] = second
+
+	world := &World{towns: []*Town{first, second}}
+	if world.validate() == nil {
+		t.Fatal("expected a link which does not lead back to be invalid")
+	}
+}
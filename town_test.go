@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Snapshot of a freshly generated world which can be compared byte for byte
+func generate(t *testing.T, seed int64) string {
+	t.Helper()
+
+	content, err := json.Marshal(NewWorld(seed).toRecord())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestSameSeedGeneratesSameWorld(t *testing.T) {
+	for _, seed := range []int64{1, 7, 42, 12345} {
+		if generate(t, seed) != generate(t, seed) {
+			t.Errorf("seed %d generated two different worlds", seed)
+		}
+	}
+}
+
+func TestDifferentSeedsGenerateDifferentWorlds(t *testing.T) {
+	if generate(t, 1) == generate(t, 2) {
+		t.Error("seeds 1 and 2 generated the same world")
+	}
+}
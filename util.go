@@ -2,7 +2,6 @@ package main
 
 import (
 	"unicode"
-	"math"
 	"math/rand"
 )
 
@@ -20,6 +19,17 @@ func max(x, y int) int {
 	return 0
 }
 
+// Restrict x to the range [low, high]
+func clamp(x, low, high int) int {
+	if x < low {
+		return low
+	}
+	if x > high {
+		return high
+	}
+	return x
+}
+
 func isInt(s string) bool {
 	for _, c := range s {
 		if !unicode.IsDigit(c) {
@@ -35,7 +45,8 @@ func Find[T any] (s []T, f func(T) bool) (int, T) {
 			return i, s[i]
 		}
 	}
-	return -1, s[0]
+	var empty T
+	return -1, empty
 }
 
 /*
@@ -89,29 +100,13 @@ func GetValues[T comparable, U any] (m map[T]U) [] U {
 	return values
 }
 
-func GetRandomAndRemove[T any] (s []T) ([]T, T) {
-	index := rand.Intn(len(s) - 1)
+func GetRandomAndRemove[T any] (rng *rand.Rand, s []T) ([]T, T) {
+	index := rng.Intn(len(s) - 1)
 	element := s[index]
 	s = RemoveAtIndex(s, index)
 	return s, element
 }
 
-func calculateHeuristicCost(nodeA Point, nodeB Point) int {
-	/*
-	Uses Manhattan distance in which we check nodes horizontally and vertically (not diagonally) - named because it's similar to calculating number of city blocks
-	Delta X + Delta Y
-	*/
-
-	deltaX := int(math.Abs(float64(nodeA.x - nodeB.x)))
-	deltaY := int(math.Abs(float64(nodeA.y - nodeB.y)))
-
-	if deltaX > deltaY {
-		return 14 * deltaY + 10 * (deltaX - deltaY)
-	} else {
-		return 14 * deltaX + 10 * (deltaY - deltaX)
-	}
-}
-
-func randNumInRange(min int, max int) int {
-	return rand.Intn(max - min) + min
+func randNumInRange(rng *rand.Rand, min int, max int) int {
+	return rng.Intn(max - min) + min
 }
\ No newline at end of file
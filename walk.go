@@ -0,0 +1,179 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	WALK_STEP   = 400 * time.Millisecond // Time taken to walk a single tile whilst travelling automatically
+	TRAVEL_TIME = 3 * time.Second        // Time taken to journey between neighbouring towns whilst travelling automatically
+)
+
+/*
+Walks are paths a player follows automatically a step at a time followed by any jumps to other towns
+Each step is submitted to the game loop so the player moves alongside everyone else
+*/
+type Walk struct {
+	town        *Town
+	path        []Point
+	route       []Hop // Jumps still to make once the path has been walked
+	destination string
+	stop        chan struct{}
+}
+
+/*
+Signature `goto {target}`
+Walk to the nearest target that locate would find (any other command stops the walk)
+*/
+func (player *Player) goTo(modifiers []string) {
+	target := player.resolveTarget(strings.Join(modifiers, " "), func(name string) {
+		player.goTo([]string{name})
+	})
+
+	if target == nil {
+		return
+	}
+
+	if len(target.path) == 0 {
+		player.write("You are already at " + target.name)
+		return
+	}
+
+	player.write("You set off towards " + target.name + " which is " + formatSteps(len(target.path)) + " away")
+	player.startJourney(target.path, nil, target.name)
+}
+
+// Follow a path and then a route in the background (must be run by the game loop)
+func (player *Player) startJourney(path []Point, route []Hop, destination string) {
+	player.stopWalking()
+
+	walk := &Walk{player.currentTown, path, route, destination, make(chan struct{})}
+	player.walk = walk
+
+	world := getWorldInstance()
+	delay := walk.nextDelay()
+
+	go func() {
+		for {
+			timer := time.NewTimer(delay)
+
+			select {
+			case <-walk.stop:
+				timer.Stop()
+				return
+			case <-timer.C:
+				// Execute waits for the step to be run so the delay it returns is safe to read
+				world.execute(func() {
+					delay = player.step(walk)
+				})
+			}
+		}
+	}()
+}
+
+// Time until the next step or jump of a walk
+func (walk *Walk) nextDelay() time.Duration {
+	if len(walk.path) > 0 {
+		return WALK_STEP
+	}
+	return TRAVEL_TIME
+}
+
+// Stop following a path returning whether there was one (must be run by the game loop)
+func (player *Player) stopWalking() bool {
+	if player.walk == nil {
+		return false
+	}
+
+	close(player.walk.stop)
+	player.walk = nil
+	return true
+}
+
+/*
+Take the next step along a path triggering whatever lies there just as moving does
+Once the path has been walked the next jump along the route is made after which the path leads to the following gate
+Returns how long until the next step (which does not matter once the walk is over)
+*/
+func (player *Player) step(walk *Walk) time.Duration {
+	// The walk may have been interrupted whilst this step was waiting to run
+	if player.walk != walk {
+		return 0
+	}
+
+	if player.currentTown != walk.town {
+		player.stopWalking()
+		return 0
+	}
+
+	if len(walk.path) == 0 {
+		return player.travelStep(walk)
+	}
+
+	next := walk.path[0]
+	walk.path = walk.path[1:]
+
+	if walk.town.dungeonLayout[next.x][next.y] != 1 {
+		player.stopWalking()
+		player.displayError("Your path is blocked")
+		return 0
+	}
+
+	player.coordinates = NewPoint(next.x, next.y)
+	player.writeCompact("- Your position is " + player.coordinates.format())
+
+	if len(walk.path) == 0 && len(walk.route) == 0 {
+		player.stopWalking()
+		player.write("You have arrived at " + walk.destination)
+	} else if len(walk.path) == 0 {
+		player.write("You reach the gate to " + walk.route[0].town.name)
+	}
+
+	player.triggerEvents()
+
+	// Trading or fighting takes over so the walk cannot carry on
+	if player.options != nil {
+		player.stopWalking()
+	}
+
+	return walk.nextDelay()
+}
+
+// Make the next jump along the route of a walk
+func (player *Player) travelStep(walk *Walk) time.Duration {
+	hop := walk.route[0]
+	walk.route = walk.route[1:]
+
+	player.jump([]string{directionNames[hop.direction]})
+
+	if player.currentTown != hop.town {
+		player.stopWalking()
+		return 0
+	}
+
+	walk.town = player.currentTown
+
+	if len(walk.route) == 0 {
+		player.stopWalking()
+		player.write("You have arrived at " + walk.destination)
+		return 0
+	}
+
+	walk.path = player.pathToGate(walk.route[0])
+	if walk.path == nil {
+		player.stopWalking()
+		return 0
+	}
+
+	player.write("You carry on towards " + walk.destination + " which is " + formatJumps(len(walk.route)) + " away")
+	return walk.nextDelay()
+}
+
+func formatSteps(steps int) string {
+	if steps == 1 {
+		return "1 step"
+	}
+	return strconv.Itoa(steps) + " steps"
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+WorldRecord is the form in which the world is kept on disk
+Adjacent towns are referenced by name as every town within a world has a unique name
+*/
+type WorldRecord struct {
+	Seed  int64        `json:"seed"` // Seed the world was originally generated from
+	Towns []TownRecord `json:"towns"`
+}
+
+type TownRecord struct {
+	Name          string             `json:"name"`
+	Description   string             `json:"description"`
+	Layout        []string           `json:"layout"` // One string per row using the same characters as the map ('#' walkable, '/' wall)
+	Items         []PlacedItemRecord `json:"items"`
+	Events        []EventRecord      `json:"events"`
+	Corpses       []CorpseRecord     `json:"corpses,omitempty"`
+	AdjacentTowns [4]string          `json:"adjacentTowns"` // [North, South, East, West] with "" for no town
+	Gates         [4]*GateRecord     `json:"gates"`         // [North, South, East, West] with null for no gate
+}
+
+type GateRecord struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type PlacedItemRecord struct {
+	ItemRecord
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type EventRecord struct {
+	Type    EventType `json:"type"`
+	Name    string    `json:"name,omitempty"`
+	X       int       `json:"x"`
+	Y       int       `json:"y"`
+	Health  int       `json:"health,omitempty"`
+	Attack  int       `json:"attack,omitempty"`
+	Defense int       `json:"defense,omitempty"`
+}
+
+type CorpseRecord struct {
+	Owner    string       `json:"owner"`
+	Items    []ItemRecord `json:"items"`
+	X        int          `json:"x"`
+	Y        int          `json:"y"`
+	DecaysAt time.Time    `json:"decaysAt"`
+}
+
+// Take a snapshot of the world (must be run by the game loop)
+func (world *World) toRecord() *WorldRecord {
+	record := new(WorldRecord)
+	record.Seed = world.seed
+
+	for _, town := range world.towns {
+		townRecord := TownRecord{Name: town.name, Description: town.description}
+
+		for y := 0; y < config.Height; y++ {
+			var row strings.Builder
+			for x := 0; x < config.Width; x++ {
+				if town.dungeonLayout[x][y] == 1 {
+					row.WriteByte('#')
+				} else {
+					row.WriteByte('/')
+				}
+			}
+			townRecord.Layout = append(townRecord.Layout, row.String())
+		}
+
+		for _, item := range town.items {
+			townRecord.Items = append(townRecord.Items, PlacedItemRecord{item.toRecord(), item.coordinates.x, item.coordinates.y})
+		}
+
+		for _, event := range town.events {
+			townRecord.Events = append(townRecord.Events, EventRecord{event.eventType, event.name, event.coordinates.x, event.coordinates.y, event.health, event.attack, event.defense})
+		}
+
+		for _, corpse := range town.corpses {
+			corpseRecord := CorpseRecord{corpse.owner, nil, corpse.coordinates.x, corpse.coordinates.y, corpse.decaysAt}
+			for _, item := range corpse.items {
+				corpseRecord.Items = append(corpseRecord.Items, item.toRecord())
+			}
+			townRecord.Corpses = append(townRecord.Corpses, corpseRecord)
+		}
+
+		for i, adjacentTown := range town.adjacentTowns {
+			if adjacentTown != nil {
+				townRecord.AdjacentTowns[i] = adjacentTown.name
+			}
+		}
+
+		for i, gate := range town.gates {
+			if gate != nil {
+				townRecord.Gates[i] = &GateRecord{gate.x, gate.y}
+			}
+		}
+
+		record.Towns = append(record.Towns, townRecord)
+	}
+
+	return record
+}
+
+// Rebuild a world from a snapshot
+func NewWorldFromRecord(record *WorldRecord) (*World, error) {
+	w := new(World)
+	w.commands = make(chan func())
+	w.sessions = NewSessionManager()
+	w.channels = make(map[string]*ChatChannel)
+	w.seed = record.Seed
+	w.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	if len(record.Towns) == 0 {
+		return nil, errors.New("world contains no towns")
+	}
+
+	for _, townRecord := range record.Towns {
+		if len(townRecord.Layout) != config.Height {
+			return nil, fmt.Errorf("town %s has %d rows but the map is configured to be %d high", townRecord.Name, len(townRecord.Layout), config.Height)
+		}
+
+		town := new(Town)
+		town.name = townRecord.Name
+		town.description = townRecord.Description
+		town.adjacentTowns = make([]*Town, 4)
+		town.dungeonLayout = newLayout(config.Width, config.Height)
+
+		for y, row := range townRecord.Layout {
+			if len(row) != config.Width {
+				return nil, fmt.Errorf("town %s has a row of %d tiles but the map is configured to be %d wide", townRecord.Name, len(row), config.Width)
+			}
+
+			for x := 0; x < config.Width; x++ {
+				if row[x] == '#' {
+					town.dungeonLayout[x][y] = 1
+				}
+			}
+		}
+
+		for _, item := range townRecord.Items {
+			town.items = append(town.items, itemFromRecord(item.ItemRecord, *NewPoint(item.X, item.Y)))
+		}
+
+		for _, event := range townRecord.Events {
+			loaded := NewEvent(*NewPoint(event.X, event.Y), event.Type, event.Name)
+			loaded.health = event.Health
+			loaded.attack = event.Attack
+			loaded.defense = event.Defense
+
+			// Enemies saved before they had stats are given some
+			if loaded.eventType == Enemy && loaded.health <= 0 {
+				loaded = NewEnemy(w.rng, loaded.coordinates, loaded.name)
+			}
+
+			town.events = append(town.events, loaded)
+		}
+
+		for _, corpseRecord := range townRecord.Corpses {
+			corpse := &Corpse{corpseRecord.Owner, nil, *NewPoint(corpseRecord.X, corpseRecord.Y), corpseRecord.DecaysAt}
+			for _, item := range corpseRecord.Items {
+				corpse.items = append(corpse.items, itemFromRecord(item, corpse.coordinates))
+			}
+			town.corpses = append(town.corpses, corpse)
+		}
+
+		w.towns = append(w.towns, town)
+	}
+
+	// Link towns once they all exist
+	for i, townRecord := range record.Towns {
+		for direction, name := range townRecord.AdjacentTowns {
+			if name == "" {
+				continue
+			}
+
+			adjacentTown := w.findTown(name)
+			if adjacentTown == nil {
+				return nil, fmt.Errorf("town %s leads to unknown town %s", townRecord.Name, name)
+			}
+
+			w.towns[i].adjacentTowns[direction] = adjacentTown
+		}
+
+		for direction, gate := range townRecord.Gates {
+			if gate == nil {
+				continue
+			}
+
+			if gate.X < 0 || gate.Y < 0 || gate.X >= config.Width || gate.Y >= config.Height || w.towns[i].dungeonLayout[gate.X][gate.Y] != 1 {
+				return nil, fmt.Errorf("town %s has a gate at [%d,%d] which is not walkable", townRecord.Name, gate.X, gate.Y)
+			}
+
+			w.towns[i].gates[direction] = NewPoint(gate.X, gate.Y)
+		}
+
+		// Worlds saved before towns had gates are given some
+		w.towns[i].placeGates(w.rng)
+	}
+
+	return w, nil
+}
+
+// Read a world which was previously saved to a file
+func LoadWorld(path string) (*World, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	record := new(WorldRecord)
+	if err := json.Unmarshal(content, record); err != nil {
+		return nil, err
+	}
+
+	return NewWorldFromRecord(record)
+}
+
+// Load the world from a file if one has been saved, otherwise generate a new one
+func loadOrCreateWorld(path string, seed int64) (*World, error) {
+	if path == "" {
+		return NewWorld(seed), nil
+	}
+
+	world, err := LoadWorld(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewWorld(seed), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not load world from %s: %w", path, err)
+	}
+
+	fmt.Println("Loaded", len(world.towns), "towns from", path, "originally generated with seed", world.seed)
+
+	// Worlds saved before towns were laid out upon a grid may have towns which cannot be reached
+	if err := world.validate(); err != nil {
+		fmt.Println("Warning:", err)
+	}
+
+	return world, nil
+}
+
+/*
+Save the world to a file (written to a temporary file first so a crash never leaves half a world)
+Every player online is saved from the same moment so items are never lost or duplicated between the two
+Saves happen one at a time so they never share the temporary file and a newer snapshot is never replaced by an older one
+*/
+func (world *World) save(path string) error {
+	world.saving.Lock()
+	defer world.saving.Unlock()
+
+	var record *WorldRecord
+	var players []*PlayerRecord
+	world.execute(func() {
+		record = world.toRecord()
+		for _, player := range world.sessions.players() {
+			players = append(players, player.toRecord())
+		}
+	})
+
+	for _, player := range players {
+		if err := accountStore.save(player); err != nil {
+			fmt.Println("Failed to save account", player.Name, err)
+		}
+	}
+
+	content, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path+".tmp", content, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// Periodically save the world and its players until the program exits
+func (world *World) autosave(path string, interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := world.save(path); err != nil {
+			fmt.Println("Failed to save world:", err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+const TEST_SAVES = 20
+
+func TestConcurrentSaves(t *testing.T) {
+	world := getWorldInstance()
+	path := filepath.Join(t.TempDir(), "world.json")
+
+	// Autosaves and shutdown may save at the same moment
+	errs := make(chan error, TEST_SESSIONS*TEST_SAVES)
+	var wg sync.WaitGroup
+
+	for i := 0; i < TEST_SESSIONS; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < TEST_SAVES; j++ {
+				if err := world.save(path); err != nil {
+					errs <- err
+					continue
+				}
+
+				// Whatever is on disk after a save must be a whole world
+				content, err := os.ReadFile(path)
+				if err == nil {
+					err = json.Unmarshal(content, new(WorldRecord))
+				}
+				errs <- err
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loaded, err := LoadWorld(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(loaded.towns) != len(world.towns) {
+		t.Fatalf("expected %d towns to be saved but loaded %d", len(world.towns), len(loaded.towns))
+	}
+}
+
+func TestLoadCorruptWorld(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "world.json")
+	if err := os.WriteFile(path, []byte("{bad"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if world, err := loadOrCreateWorld(path, 1); err == nil || world != nil {
+		t.Fatal("expected a corrupt world file to be reported as an error")
+	}
+}
+
+func TestSaveIncludesPlayersOnline(t *testing.T) {
+	previousStore := accountStore
+	accountStore = NewAccountStore(t.TempDir())
+	defer func() {
+		accountStore = previousStore
+	}()
+
+	world := getWorldInstance()
+	player := newTestPlayer(t, "hoarder")
+	world.execute(func() {
+		player.gold = 1234
+	})
+
+	if err := world.save(filepath.Join(t.TempDir(), "world.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(accountStore.path(player.name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := new(PlayerRecord)
+	if err := json.Unmarshal(content, record); err != nil {
+		t.Fatal(err)
+	}
+
+	if record.Name != player.name || record.Gold != 1234 {
+		t.Fatalf("expected %s to be saved with 1234 gold but saved %s with %d", player.name, record.Name, record.Gold)
+	}
+}